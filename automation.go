@@ -0,0 +1,107 @@
+package uaparser
+
+import (
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// Automation frameworks reported by IAutomation.Framework. HeadlessChrome is
+// also what Puppeteer, Playwright and Selenium drive by default, whose own
+// names never appear in the UA.
+const (
+	AutomationHeadlessChrome = "HeadlessChrome"
+	AutomationPhantomJS      = "PhantomJS"
+	AutomationSlimerJS       = "SlimerJS"
+	AutomationJSDOM          = "jsdom"
+	AutomationCypress        = "Cypress"
+	AutomationLighthouse     = "Lighthouse"
+	AutomationElectron       = "Electron"
+)
+
+// Signals reported by IAutomation.Signals.
+const (
+	// SignalUAToken is a token of an automation framework in the UA.
+	SignalUAToken = "ua-token"
+	// SignalHeadlessBrand is a HeadlessChrome brand in Sec-CH-UA, sent even
+	// when the UA was overridden.
+	SignalHeadlessBrand = "headless-brand"
+	// SignalMissingGrease is a Sec-CH-UA without the GREASE brand every
+	// Chromium-based browser adds, as written by hand-made HTTP clients.
+	SignalMissingGrease = "missing-grease"
+)
+
+type automationToken struct {
+	token      string // lowercase
+	framework  string
+	confidence float64
+}
+
+// automationTokens are the UA tokens of automation frameworks. Electron is
+// only a hint, since desktop apps built on it are common.
+var automationTokens = []automationToken{
+	{"headlesschrome", AutomationHeadlessChrome, 0.95},
+	{"phantomjs", AutomationPhantomJS, 1},
+	{"slimerjs", AutomationSlimerJS, 1},
+	{"jsdom/", AutomationJSDOM, 1},
+	{"cypress/", AutomationCypress, 1},
+	{"chrome-lighthouse", AutomationLighthouse, 0.9},
+	{"electron/", AutomationElectron, 0.5},
+}
+
+const missingGreaseConfidence = 0.7
+
+// greaseBrandReg matches the GREASE brands of Chromium, e.g. "Not_A Brand"
+// or "Not)A;Brand", and " Not A;Brand" with the leading space of Chrome 89
+// to 95.
+var greaseBrandReg = regexp.MustCompile(`(?i)^[ ()\-./:;=?_]*not[ ()\-./:;=?_]*a[ ()\-./:;=?_]*brand$`)
+
+// DetectAutomation looks for headless browsers and automation frameworks in
+// ua and, if given, in the brands of hints.
+func DetectAutomation(ua string, hints ...ClientHints) IAutomation {
+	if len(hints) > 0 {
+		return detectAutomation(ua, hints[0], true)
+	}
+	return detectAutomation(ua, ClientHints{}, false)
+}
+
+func detectAutomation(ua string, hints ClientHints, withCH bool) IAutomation {
+	var a IAutomation
+	best := 0.0
+	found := func(signal, framework string, confidence float64) {
+		if !slices.Contains(a.Signals, signal) {
+			a.Signals = append(a.Signals, signal)
+		}
+		a.Confidence = 1 - (1-a.Confidence)*(1-confidence)
+		if framework != "" && confidence > best {
+			a.Framework, best = framework, confidence
+		}
+	}
+
+	lowered := strings.ToLower(ua)
+	for _, t := range automationTokens {
+		if strings.Contains(lowered, t.token) {
+			found(SignalUAToken, t.framework, t.confidence)
+		}
+	}
+	if !withCH {
+		return a
+	}
+	brands := hints.Brands
+	if len(brands) == 0 {
+		brands = hints.FullVersionList
+	}
+	grease := false
+	for _, brand := range brands {
+		switch {
+		case strings.EqualFold(brand.Name, AutomationHeadlessChrome):
+			found(SignalHeadlessBrand, AutomationHeadlessChrome, 0.95)
+		case greaseBrandReg.MatchString(brand.Name):
+			grease = true
+		}
+	}
+	if len(brands) > 0 && !grease {
+		found(SignalMissingGrease, "", missingGreaseConfidence)
+	}
+	return a
+}
@@ -0,0 +1,70 @@
+package uaparser
+
+import (
+	"encoding/json"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestDetectAutomation(t *testing.T) {
+	tests := []struct {
+		ua        string
+		framework string
+	}{
+		{"Mozilla/5.0 (X11; Linux x86_64) AppleWebKit/537.36 (KHTML, like Gecko) HeadlessChrome/120.0.0.0 Safari/537.36", AutomationHeadlessChrome},
+		{"Mozilla/5.0 (Unknown; Linux x86_64) AppleWebKit/538.1 (KHTML, like Gecko) PhantomJS/2.1.1 Safari/538.1", AutomationPhantomJS},
+		{"Mozilla/5.0 (linux) AppleWebKit/537.36 (KHTML, like Gecko) jsdom/22.1.0", AutomationJSDOM},
+		{"Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Cypress/13.6.1 Chrome/114.0.5735.289 Electron/25.8.4 Safari/537.36", AutomationCypress},
+		{"Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.6099.56 Electron/28.0.0 Safari/537.36", AutomationElectron},
+		{"Mozilla/5.0 (Linux; Android 11; moto g power (2022)) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/119.0.0.0 Mobile Safari/537.36 Chrome-Lighthouse", AutomationLighthouse},
+		{cacheChromeUA, ""},
+		{cacheFirefoxUA, ""},
+	}
+	for _, tc := range tests {
+		a := DetectAutomation(tc.ua)
+		assert.Equal(t, tc.framework, a.Framework, tc.ua)
+		assert.Equal(t, tc.framework != "", a.Confidence > 0, tc.ua)
+	}
+
+	// Cypress runs in Electron: both count, Cypress wins
+	a := DetectAutomation(tests[3].ua)
+	assert.Equal(t, []string{SignalUAToken}, a.Signals)
+	assert.InDelta(t, 1.0, a.Confidence, 1e-9)
+}
+
+func TestDetectAutomation_Hints(t *testing.T) {
+	grease := IBrand{Name: "Not_A Brand", Version: "8"}
+	chrome := IBrand{Name: "Google Chrome", Version: "120"}
+
+	a := DetectAutomation(cacheChromeUA, ClientHints{Brands: []IBrand{grease, {Name: "Chromium", Version: "120"}, chrome}})
+	assert.Zero(t, a)
+	for _, name := range []string{"Not A(Brand", "Not)A;Brand", "Not/A)Brand", "Not.A/Brand", " Not A;Brand"} {
+		assert.Zero(t, DetectAutomation(cacheChromeUA, ClientHints{Brands: []IBrand{{Name: name, Version: "99"}, chrome}}), name)
+	}
+
+	legacy := NewUAParser(cacheChromeUA).WithHeaders(map[string]string{
+		CHHeader: `" Not A;Brand";v="99", "Chromium";v="90", "Google Chrome";v="90"`,
+	}).Result()
+	assert.Zero(t, legacy.Automation)
+
+	// the UA was overridden but the brands still tell
+	a = DetectAutomation(cacheChromeUA, ClientHints{Brands: []IBrand{grease, {Name: "HeadlessChrome", Version: "120"}}})
+	assert.Equal(t, IAutomation{Framework: AutomationHeadlessChrome, Confidence: 0.95, Signals: []string{SignalHeadlessBrand}}, a)
+
+	a = DetectAutomation(cacheChromeUA, ClientHints{Brands: []IBrand{chrome}})
+	assert.Equal(t, IAutomation{Confidence: missingGreaseConfidence, Signals: []string{SignalMissingGrease}}, a)
+
+	result := NewUAParser(cacheChromeUA).WithHeaders(map[string]string{CHHeader: `"Google Chrome";v="120"`}).Result()
+	assert.Equal(t, a, result.Automation)
+}
+
+func TestResult_AutomationJSON(t *testing.T) {
+	data, err := json.Marshal(NewUAParser(cacheChromeUA).Result())
+	require.NoError(t, err)
+	assert.NotContains(t, string(data), "automation")
+
+	data, err = json.Marshal(NewUAParser("Mozilla/5.0 (linux) AppleWebKit/537.36 (KHTML, like Gecko) jsdom/22.1.0").Result())
+	require.NoError(t, err)
+	assert.Contains(t, string(data), `"automation":{"framework":"jsdom","confidence":1,"signals":["ua-token"]}`)
+}
@@ -0,0 +1,263 @@
+package uaparser
+
+import (
+	"slices"
+	"strings"
+)
+
+// BotCategory tells what a bot is used for.
+type BotCategory string
+
+const (
+	BotSearchCrawler     BotCategory = "search-crawler"
+	BotAITrainingCrawler BotCategory = "ai-training-crawler"
+	BotAIAssistant       BotCategory = "ai-assistant" // fetches pages on behalf of an AI assistant's user
+	BotSEOTool           BotCategory = "seo-tool"
+	BotMonitoring        BotCategory = "monitoring"
+	BotLinkPreview       BotCategory = "link-preview"
+	BotArchiver          BotCategory = "archiver"
+	BotCLI               BotCategory = "cli"
+	BotLibrary           BotCategory = "library"
+	// BotCrawler and BotFetcher are used for bots found by the Bots rules
+	// but missing from the BotTable.
+	BotCrawler BotCategory = "crawler"
+	BotFetcher BotCategory = "fetcher"
+)
+
+// BotInfo describes the bot a UA belongs to.
+type BotInfo struct {
+	IsBot    bool        `json:"isBot"`
+	Name     string      `json:"name,omitempty"`
+	Version  string      `json:"version,omitempty"`
+	Category BotCategory `json:"category,omitempty"`
+	// Operator is the company or project running the bot.
+	Operator string `json:"operator,omitempty"`
+	// URL documents the bot, e.g. how to verify or block it.
+	URL string `json:"url,omitempty"`
+	// AI is set for bots whose content feeds AI models or assistants.
+	AI bool `json:"ai,omitempty"`
+}
+
+// BotEntry is a row of a BotTable.
+type BotEntry struct {
+	// Token identifies the bot, matched case-insensitively against the UA.
+	// When several entries match, the one with the longest token wins.
+	Token    string
+	Name     string
+	Category BotCategory
+	Operator string
+	URL      string
+	AI       bool
+}
+
+// BotTable detects bots from a table of known bots, backed by the Bots
+// rules for bots missing from the table. It is never modified, so it can be
+// shared by any number of goroutines.
+type BotTable struct {
+	entries []BotEntry // lowercased tokens, added entries first
+}
+
+// defaultBotTable holds defaultBotEntries.
+var defaultBotTable = newBotTable(nil, defaultBotEntries)
+
+// botParser finds bots missing from bot tables.
+var botParser = NewParser(WithExtensions(Bots))
+
+// NewBotTable returns a table with the built-in bots and entries, which
+// take precedence over built-in entries with the same token.
+func NewBotTable(entries ...BotEntry) *BotTable {
+	return defaultBotTable.With(entries...)
+}
+
+// With returns a table with the bots of t and entries, which take
+// precedence over those of t with the same token.
+func (t *BotTable) With(entries ...BotEntry) *BotTable {
+	return newBotTable(entries, t.entries)
+}
+
+func newBotTable(entries, base []BotEntry) *BotTable {
+	t := &BotTable{entries: slices.Concat(entries, base)}
+	for i := range t.entries[:len(entries)] {
+		t.entries[i].Token = strings.ToLower(t.entries[i].Token)
+	}
+	return t
+}
+
+// Entries returns the entries of t.
+func (t *BotTable) Entries() []BotEntry {
+	return slices.Clone(t.entries)
+}
+
+// Detect tells whether ua belongs to a bot, and which.
+func (t *BotTable) Detect(ua string) BotInfo {
+	in := &input{ua: checkUA(ua)}
+	browser, _ := botParser.browser(in, botParser.rules(), botParser.budget.start())
+
+	lower := strings.ToLower(in.ua)
+	var entry *BotEntry
+	for i, e := range t.entries {
+		if (entry == nil || len(e.Token) > len(entry.Token)) && e.Token != "" && strings.Contains(lower, e.Token) {
+			entry = &t.entries[i]
+		}
+	}
+	if entry != nil {
+		info := BotInfo{
+			IsBot:    true,
+			Name:     entry.Name,
+			Category: entry.Category,
+			Operator: entry.Operator,
+			URL:      entry.URL,
+			AI:       entry.AI,
+		}
+		// the version comes from the Bots rules, if they found the same bot
+		if name := strings.ToLower(browser.Name); name != "" &&
+			(strings.Contains(name, strings.Trim(entry.Token, "/ ")) || name == strings.ToLower(entry.Name)) {
+			info.Version = browser.Version
+		}
+		return info
+	}
+
+	category, ok := map[string]BotCategory{
+		CLI:     BotCLI,
+		Crawler: BotCrawler,
+		Fetcher: BotFetcher,
+		Library: BotLibrary,
+	}[browser.Type]
+	if !ok {
+		return BotInfo{}
+	}
+	return BotInfo{IsBot: true, Name: browser.Name, Version: browser.Version, Category: category}
+}
+
+// DetectBot tells whether ua belongs to a bot, and which, using the
+// built-in bot table.
+func DetectBot(ua string) BotInfo {
+	return defaultBotTable.Detect(ua)
+}
+
+const (
+	googleCrawlersURL = "https://developers.google.com/search/docs/crawling-indexing/overview-google-crawlers"
+	metaCrawlersURL   = "https://developers.facebook.com/docs/sharing/webmasters/web-crawlers"
+	openAIBotsURL     = "https://platform.openai.com/docs/bots"
+	yandexBotsURL     = "https://yandex.com/bots"
+)
+
+// defaultBotEntries are the bots known to DetectBot.
+var defaultBotEntries = []BotEntry{
+	// search engines
+	{Token: "googlebot", Name: "Googlebot", Category: BotSearchCrawler, Operator: "Google",
+		URL: "https://developers.google.com/search/docs/crawling-indexing/googlebot"},
+	{Token: "storebot-google", Name: "Storebot-Google", Category: BotSearchCrawler, Operator: "Google", URL: googleCrawlersURL},
+	{Token: "adsbot-google", Name: "AdsBot-Google", Category: BotMonitoring, Operator: "Google", URL: googleCrawlersURL},
+	{Token: "mediapartners-google", Name: "Mediapartners-Google", Category: BotMonitoring, Operator: "Google", URL: googleCrawlersURL},
+	{Token: "google-inspectiontool", Name: "Google-InspectionTool", Category: BotSEOTool, Operator: "Google", URL: googleCrawlersURL},
+	{Token: "bingbot", Name: "Bingbot", Category: BotSearchCrawler, Operator: "Microsoft",
+		URL: "https://www.bing.com/webmasters/help/which-crawlers-does-bing-use-8c184ec0"},
+	{Token: "bingpreview", Name: "BingPreview", Category: BotLinkPreview, Operator: "Microsoft"},
+	{Token: "yandexbot", Name: "YandexBot", Category: BotSearchCrawler, Operator: "Yandex", URL: yandexBotsURL},
+	{Token: "yandeximages", Name: "YandexImages", Category: BotSearchCrawler, Operator: "Yandex", URL: yandexBotsURL},
+	{Token: "yandexmobilebot", Name: "YandexMobileBot", Category: BotSearchCrawler, Operator: "Yandex", URL: yandexBotsURL},
+	{Token: "baiduspider", Name: "Baiduspider", Category: BotSearchCrawler, Operator: "Baidu",
+		URL: "https://www.baidu.com/search/spider.html"},
+	{Token: "duckduckbot", Name: "DuckDuckBot", Category: BotSearchCrawler, Operator: "DuckDuckGo",
+		URL: "https://duckduckgo.com/duckduckbot"},
+	{Token: "applebot", Name: "Applebot", Category: BotSearchCrawler, Operator: "Apple",
+		URL: "https://support.apple.com/en-us/119829", AI: true},
+	{Token: "yeti/", Name: "Yeti", Category: BotSearchCrawler, Operator: "Naver"},
+	{Token: "seznambot", Name: "SeznamBot", Category: BotSearchCrawler, Operator: "Seznam"},
+	{Token: "mojeekbot", Name: "MojeekBot", Category: BotSearchCrawler, Operator: "Mojeek", URL: "https://www.mojeek.com/bot.html"},
+	{Token: "coccocbot", Name: "coccocbot", Category: BotSearchCrawler, Operator: "Coc Coc"},
+	{Token: "sogou web spider", Name: "Sogou Web Spider", Category: BotSearchCrawler, Operator: "Sogou"},
+	{Token: "360spider", Name: "360Spider", Category: BotSearchCrawler, Operator: "Qihoo 360"},
+	{Token: "yisouspider", Name: "YisouSpider", Category: BotSearchCrawler, Operator: "Alibaba"},
+	{Token: "slurp", Name: "Yahoo! Slurp", Category: BotSearchCrawler, Operator: "Yahoo"},
+	{Token: "petalbot", Name: "PetalBot", Category: BotSearchCrawler, Operator: "Huawei", AI: true},
+	{Token: "aspiegelbot", Name: "AspiegelBot", Category: BotSearchCrawler, Operator: "Huawei"},
+
+	// AI
+	{Token: "gptbot", Name: "GPTBot", Category: BotAITrainingCrawler, Operator: "OpenAI", URL: openAIBotsURL, AI: true},
+	{Token: "oai-searchbot", Name: "OAI-SearchBot", Category: BotSearchCrawler, Operator: "OpenAI", URL: openAIBotsURL, AI: true},
+	{Token: "chatgpt-user", Name: "ChatGPT-User", Category: BotAIAssistant, Operator: "OpenAI", URL: openAIBotsURL, AI: true},
+	{Token: "claudebot", Name: "ClaudeBot", Category: BotAITrainingCrawler, Operator: "Anthropic", AI: true},
+	{Token: "claude-web", Name: "Claude-Web", Category: BotAIAssistant, Operator: "Anthropic", AI: true},
+	{Token: "anthropic-ai", Name: "anthropic-ai", Category: BotAITrainingCrawler, Operator: "Anthropic", AI: true},
+	{Token: "applebot-extended", Name: "Applebot-Extended", Category: BotAITrainingCrawler, Operator: "Apple",
+		URL: "https://support.apple.com/en-us/119829", AI: true},
+	{Token: "google-extended", Name: "Google-Extended", Category: BotAITrainingCrawler, Operator: "Google", URL: googleCrawlersURL, AI: true},
+	{Token: "googleother", Name: "GoogleOther", Category: BotAITrainingCrawler, Operator: "Google", URL: googleCrawlersURL, AI: true},
+	{Token: "google-cloudvertexbot", Name: "Google-CloudVertexBot", Category: BotAITrainingCrawler, Operator: "Google", URL: googleCrawlersURL, AI: true},
+	{Token: "perplexitybot", Name: "PerplexityBot", Category: BotSearchCrawler, Operator: "Perplexity",
+		URL: "https://docs.perplexity.ai/guides/bots", AI: true},
+	{Token: "perplexity-user", Name: "Perplexity-User", Category: BotAIAssistant, Operator: "Perplexity",
+		URL: "https://docs.perplexity.ai/guides/bots", AI: true},
+	{Token: "duckassistbot", Name: "DuckAssistBot", Category: BotAIAssistant, Operator: "DuckDuckGo",
+		URL: "https://duckduckgo.com/duckassistbot/", AI: true},
+	{Token: "ccbot", Name: "CCBot", Category: BotAITrainingCrawler, Operator: "Common Crawl", URL: "https://commoncrawl.org/ccbot", AI: true},
+	{Token: "amazonbot", Name: "Amazonbot", Category: BotAITrainingCrawler, Operator: "Amazon",
+		URL: "https://developer.amazon.com/amazonbot", AI: true},
+	{Token: "bytespider", Name: "Bytespider", Category: BotAITrainingCrawler, Operator: "ByteDance", AI: true},
+	{Token: "meta-externalagent", Name: "Meta-ExternalAgent", Category: BotAITrainingCrawler, Operator: "Meta", URL: metaCrawlersURL, AI: true},
+	{Token: "facebookbot", Name: "FacebookBot", Category: BotAITrainingCrawler, Operator: "Meta", URL: metaCrawlersURL, AI: true},
+	{Token: "ai2bot", Name: "AI2Bot", Category: BotAITrainingCrawler, Operator: "Allen Institute for AI", AI: true},
+	{Token: "cohere-ai", Name: "cohere-ai", Category: BotAIAssistant, Operator: "Cohere", AI: true},
+	{Token: "diffbot", Name: "Diffbot", Category: BotAITrainingCrawler, Operator: "Diffbot", AI: true},
+	{Token: "imagesiftbot", Name: "ImagesiftBot", Category: BotAITrainingCrawler, Operator: "Hive", AI: true},
+	{Token: "timpibot", Name: "Timpibot", Category: BotAITrainingCrawler, Operator: "Timpi", AI: true},
+	{Token: "velenpublicwebcrawler", Name: "VelenPublicWebCrawler", Category: BotAITrainingCrawler, Operator: "Velen", AI: true},
+	{Token: "omgili", Name: "Omgilibot", Category: BotAITrainingCrawler, Operator: "Webz.io", AI: true},
+	{Token: "webzio-extended", Name: "Webzio-Extended", Category: BotAITrainingCrawler, Operator: "Webz.io", AI: true},
+	{Token: "youbot", Name: "YouBot", Category: BotAITrainingCrawler, Operator: "You.com", AI: true},
+
+	// SEO tools
+	{Token: "ahrefsbot", Name: "AhrefsBot", Category: BotSEOTool, Operator: "Ahrefs", URL: "https://ahrefs.com/robot"},
+	{Token: "ahrefssiteaudit", Name: "AhrefsSiteAudit", Category: BotSEOTool, Operator: "Ahrefs", URL: "https://ahrefs.com/robot/site-audit"},
+	{Token: "semrushbot", Name: "SemrushBot", Category: BotSEOTool, Operator: "Semrush", URL: "https://www.semrush.com/bot/"},
+	{Token: "semrushbot-ocob", Name: "SemrushBot-OCOB", Category: BotAITrainingCrawler, Operator: "Semrush", AI: true},
+	{Token: "siteauditbot", Name: "SiteAuditBot", Category: BotSEOTool, Operator: "Semrush"},
+	{Token: "splitsignalbot", Name: "SplitSignalBot", Category: BotSEOTool, Operator: "Semrush"},
+	{Token: "mj12bot", Name: "MJ12bot", Category: BotSEOTool, Operator: "Majestic", URL: "https://mj12bot.com/"},
+	{Token: "dotbot", Name: "DotBot", Category: BotSEOTool, Operator: "Moz", URL: "https://moz.com/help/moz-procedures/crawlers/dotbot"},
+	{Token: "rogerbot", Name: "rogerbot", Category: BotSEOTool, Operator: "Moz", URL: "https://moz.com/help/moz-procedures/crawlers/rogerbot"},
+	{Token: "dataforseobot", Name: "DataForSeoBot", Category: BotSEOTool, Operator: "DataForSEO", AI: true},
+	{Token: "screaming frog seo spider", Name: "Screaming Frog SEO Spider", Category: BotSEOTool, Operator: "Screaming Frog",
+		URL: "https://www.screamingfrog.co.uk/seo-spider/"},
+	{Token: "aihitbot", Name: "aiHitBot", Category: BotSEOTool, Operator: "aiHit"},
+	{Token: "magpie-crawler", Name: "Magpie-Crawler", Category: BotSEOTool, Operator: "Brandwatch"},
+	{Token: "turnitinbot", Name: "TurnitinBot", Category: BotCrawler, Operator: "Turnitin"},
+
+	// monitoring
+	{Token: "uptimerobot", Name: "UptimeRobot", Category: BotMonitoring, Operator: "UptimeRobot", URL: "https://uptimerobot.com/"},
+	{Token: "pingdom", Name: "Pingdom", Category: BotMonitoring, Operator: "SolarWinds"},
+	{Token: "google-site-verification", Name: "Google Site Verifier", Category: BotMonitoring, Operator: "Google", URL: googleCrawlersURL},
+	{Token: "google-safety", Name: "Google-Safety", Category: BotMonitoring, Operator: "Google", URL: googleCrawlersURL},
+	{Token: "vercelbot", Name: "Vercelbot", Category: BotMonitoring, Operator: "Vercel"},
+
+	// link previews
+	{Token: "facebookexternalhit", Name: "facebookexternalhit", Category: BotLinkPreview, Operator: "Meta", URL: metaCrawlersURL},
+	{Token: "meta-externalfetcher", Name: "Meta-ExternalFetcher", Category: BotAIAssistant, Operator: "Meta", URL: metaCrawlersURL, AI: true},
+	{Token: "twitterbot", Name: "Twitterbot", Category: BotLinkPreview, Operator: "X"},
+	{Token: "linkedinbot", Name: "LinkedInBot", Category: BotLinkPreview, Operator: "LinkedIn"},
+	{Token: "slackbot", Name: "Slackbot", Category: BotLinkPreview, Operator: "Slack", URL: "https://api.slack.com/robots"},
+	{Token: "discordbot", Name: "Discordbot", Category: BotLinkPreview, Operator: "Discord"},
+	{Token: "telegrambot", Name: "TelegramBot", Category: BotLinkPreview, Operator: "Telegram"},
+	{Token: "whatsapp", Name: "WhatsApp", Category: BotLinkPreview, Operator: "Meta"},
+	{Token: "pinterestbot", Name: "Pinterestbot", Category: BotLinkPreview, Operator: "Pinterest"},
+	{Token: "redditbot", Name: "redditbot", Category: BotLinkPreview, Operator: "Reddit"},
+	{Token: "mastodon", Name: "Mastodon", Category: BotLinkPreview, Operator: "Mastodon"},
+	{Token: "bluesky cardyb", Name: "Bluesky", Category: BotLinkPreview, Operator: "Bluesky"},
+
+	// archivers
+	{Token: "ia_archiver", Name: "ia_archiver", Category: BotArchiver, Operator: "Internet Archive"},
+	{Token: "archive.org_bot", Name: "archive.org_bot", Category: BotArchiver, Operator: "Internet Archive"},
+
+	// CLIs and libraries
+	{Token: "curl/", Name: "curl", Category: BotCLI, Operator: "curl", URL: "https://curl.se/"},
+	{Token: "wget/", Name: "Wget", Category: BotCLI, Operator: "GNU", URL: "https://www.gnu.org/software/wget/"},
+	{Token: "httpie/", Name: "HTTPie", Category: BotCLI, Operator: "HTTPie", URL: "https://httpie.io/"},
+	{Token: "python-requests/", Name: "python-requests", Category: BotLibrary, Operator: "Python Software Foundation",
+		URL: "https://requests.readthedocs.io/"},
+	{Token: "go-http-client/", Name: "Go-http-client", Category: BotLibrary, Operator: "Go", URL: "https://pkg.go.dev/net/http"},
+	{Token: "okhttp/", Name: "okhttp", Category: BotLibrary, Operator: "Square", URL: "https://square.github.io/okhttp/"},
+	{Token: "axios/", Name: "axios", Category: BotLibrary, Operator: "axios", URL: "https://axios-http.com/"},
+	{Token: "scrapy", Name: "Scrapy", Category: BotLibrary, Operator: "Zyte", URL: "https://scrapy.org/", AI: true},
+}
@@ -0,0 +1,62 @@
+package uaparser
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestDetectBot(t *testing.T) {
+	tests := []struct {
+		ua       string
+		expected BotInfo
+	}{
+		{"Mozilla/5.0 (compatible; Googlebot/2.1; +http://www.google.com/bot.html)", BotInfo{
+			IsBot: true, Name: "Googlebot", Version: "2.1", Category: BotSearchCrawler, Operator: "Google",
+			URL: "https://developers.google.com/search/docs/crawling-indexing/googlebot",
+		}},
+		{"Mozilla/5.0 AppleWebKit/537.36 (KHTML, like Gecko; compatible; GPTBot/1.2; +https://openai.com/gptbot)", BotInfo{
+			IsBot: true, Name: "GPTBot", Version: "1.2", Category: BotAITrainingCrawler, Operator: "OpenAI",
+			URL: openAIBotsURL, AI: true,
+		}},
+		{"Mozilla/5.0 AppleWebKit/537.36 (KHTML, like Gecko); compatible; ChatGPT-User/1.0; +https://openai.com/bot", BotInfo{
+			IsBot: true, Name: "ChatGPT-User", Version: "1.0", Category: BotAIAssistant, Operator: "OpenAI",
+			URL: openAIBotsURL, AI: true,
+		}},
+		{"Mozilla/5.0 (compatible; SemrushBot-OCOB/1; +https://www.semrush.com/bot/)", BotInfo{
+			IsBot: true, Name: "SemrushBot-OCOB", Version: "1", Category: BotAITrainingCrawler, Operator: "Semrush", AI: true,
+		}},
+		{"curl/8.4.0", BotInfo{
+			IsBot: true, Name: "curl", Version: "8.4.0", Category: BotCLI, Operator: "curl", URL: "https://curl.se/",
+		}},
+		{"python-requests/2.31.0", BotInfo{
+			IsBot: true, Name: "python-requests", Version: "2.31.0", Category: BotLibrary,
+			Operator: "Python Software Foundation", URL: "https://requests.readthedocs.io/",
+		}},
+		// missing from the table but found by the Bots rules
+		{"Mozilla/5.0 (compatible; YandexRenderResourcesBot/1.0; +http://yandex.com/bots)", BotInfo{
+			IsBot: true, Name: "YandexRenderResourcesBot", Version: "1.0", Category: BotCrawler,
+		}},
+		{"lua-resty-http/0.17.1 (Lua) ngx_lua/10025", BotInfo{
+			IsBot: true, Name: "lua-resty-http", Version: "0.17.1", Category: BotLibrary,
+		}},
+		{cacheChromeUA, BotInfo{}},
+	}
+	for _, tc := range tests {
+		assert.Equal(t, tc.expected, DetectBot(tc.ua), tc.ua)
+	}
+}
+
+func TestBotTable_With(t *testing.T) {
+	table := NewBotTable(
+		BotEntry{Token: "InternalProbe", Name: "Internal Probe", Category: BotMonitoring, Operator: "Us"},
+		BotEntry{Token: "curl/", Name: "curl", Category: BotMonitoring, Operator: "Our health checks"},
+	)
+	assert.Equal(t, BotInfo{IsBot: true, Name: "Internal Probe", Category: BotMonitoring, Operator: "Us"},
+		table.Detect("Mozilla/5.0 (compatible; internalprobe/3)"))
+	assert.Equal(t, "Our health checks", table.Detect("curl/8.4.0").Operator)
+
+	// the built-in table is left alone
+	assert.Equal(t, "curl", DetectBot("curl/8.4.0").Operator)
+	assert.False(t, DetectBot("Mozilla/5.0 (compatible; internalprobe/3)").IsBot)
+	assert.Equal(t, len(defaultBotEntries)+2, len(table.Entries()))
+}
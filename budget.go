@@ -0,0 +1,94 @@
+package uaparser
+
+import (
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"time"
+)
+
+// Budget limits the time a parse may spend matching patterns. Zero values
+// mean no limit.
+type Budget struct {
+	// Pattern bounds a single match of a pattern that runs on the
+	// backtracking regexp2 engine, i.e. a custom pattern the regexp package
+	// can't run even after translation, or of a translated pattern whose
+	// lookaround checks keep failing. regexp2 checks its timeout
+	// periodically, so a match may overrun it by up to about 100ms.
+	Pattern time.Duration
+	// Parse bounds a whole parse. It is checked before each pattern is
+	// tried.
+	Parse time.Duration
+}
+
+// DefaultBudget is the budget of parsers created by NewUAParser.
+var DefaultBudget = Budget{Pattern: 100 * time.Millisecond}
+
+// ErrBudgetExceeded is matched by every BudgetError.
+var ErrBudgetExceeded = errors.New("uaparser: time budget exceeded")
+
+// BudgetError reports a parse stopped because its Budget ran out. The
+// components parsed before are still returned.
+type BudgetError struct {
+	// Component is the component being parsed when the budget ran out.
+	Component string
+	// Pattern is the pattern whose match timed out. It is empty if the
+	// parse budget ran out between patterns.
+	Pattern string
+	// Elapsed is the time the parse had taken.
+	Elapsed time.Duration
+}
+
+func (e *BudgetError) Error() string {
+	if e.Pattern != "" {
+		return fmt.Sprintf("uaparser: %s: pattern %q timed out after %v", e.Component, e.Pattern, e.Elapsed)
+	}
+	return fmt.Sprintf("uaparser: %s: parse budget exceeded after %v", e.Component, e.Elapsed)
+}
+
+func (e *BudgetError) Unwrap() error {
+	return ErrBudgetExceeded
+}
+
+var budgetExceeded atomic.Uint64
+
+// BudgetExceededCount returns how many parses have been stopped by their
+// Budget since the program started.
+func BudgetExceededCount() uint64 {
+	return budgetExceeded.Load()
+}
+
+// parseBudget tracks the budget of one parse. A nil parseBudget is
+// unlimited.
+type parseBudget struct {
+	pattern  time.Duration
+	start    time.Time
+	deadline time.Time
+}
+
+func (b Budget) start() *parseBudget {
+	pb := &parseBudget{pattern: b.Pattern, start: time.Now()}
+	if b.Parse > 0 {
+		pb.deadline = pb.start.Add(b.Parse)
+	}
+	return pb
+}
+
+func (b *parseBudget) timeout() time.Duration {
+	if b == nil {
+		return 0
+	}
+	return b.pattern
+}
+
+func (b *parseBudget) exceeded() bool {
+	return b != nil && !b.deadline.IsZero() && time.Now().After(b.deadline)
+}
+
+func (b *parseBudget) error(component, pattern string) *BudgetError {
+	var elapsed time.Duration
+	if b != nil {
+		elapsed = time.Since(b.start)
+	}
+	return &BudgetError{Component: component, Pattern: pattern, Elapsed: elapsed}
+}
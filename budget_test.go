@@ -0,0 +1,58 @@
+package uaparser
+
+import (
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"strings"
+	"testing"
+	"time"
+)
+
+// catastrophic backtracks exponentially on a run of word characters not
+// followed by "c". The lookahead in a repeated group keeps it on regexp2.
+func catastrophic(t *testing.T) map[string][]regexItem {
+	rule := NewRule(`((?!bb)\w+)+c`).Output(Name, "Slow").MustBuild()
+	ext, err := NewExtension(map[string][]Rule{UABrowser: {rule}})
+	require.NoError(t, err)
+	return ext
+}
+
+func TestBudget_PatternTimeout(t *testing.T) {
+	ua := "Mozilla/5.0 (X11; Linux x86_64) " + strings.Repeat("a", 40) + "!"
+	before := BudgetExceededCount()
+
+	start := time.Now()
+	result, err := NewUAParser(ua).
+		WithExtensions(catastrophic(t)).
+		WithBudget(Budget{Pattern: 10 * time.Millisecond}).
+		Parse()
+	assert.Less(t, time.Since(start), 2*time.Second)
+
+	var budgetErr *BudgetError
+	require.ErrorAs(t, err, &budgetErr)
+	assert.ErrorIs(t, err, ErrBudgetExceeded)
+	assert.Equal(t, UABrowser, budgetErr.Component)
+	assert.Equal(t, `((?!bb)\w+)+c`, budgetErr.Pattern)
+	assert.Equal(t, IResult{UA: ua}, result)
+	assert.Equal(t, before+1, BudgetExceededCount())
+}
+
+func TestBudget_Parse(t *testing.T) {
+	ua := "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Safari/537.36"
+
+	result, err := NewUAParser(ua).WithBudget(Budget{Parse: time.Nanosecond}).Parse()
+	var budgetErr *BudgetError
+	require.True(t, errors.As(err, &budgetErr))
+	assert.Equal(t, UABrowser, budgetErr.Component)
+	assert.Empty(t, budgetErr.Pattern)
+	assert.Empty(t, result.Browser.Name)
+
+	// the partial result is returned by Result as well
+	assert.Equal(t, result, NewUAParser(ua).WithBudget(Budget{Parse: time.Nanosecond}).Result())
+
+	result, err = NewUAParser(ua).WithBudget(Budget{Parse: time.Minute}).Parse()
+	require.NoError(t, err)
+	assert.Equal(t, "Chrome", result.Browser.Name)
+	assert.Equal(t, "Windows", result.Os.Name)
+}
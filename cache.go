@@ -0,0 +1,217 @@
+package uaparser
+
+import (
+	"container/list"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ResultCache is a size-bounded LRU cache of parse results, meant to sit in
+// front of Result and Parse when a few user agents make up most of the
+// traffic. It is safe for concurrent use and may be shared by any number of
+// parsers.
+//
+// Entries are keyed on the UA, the client hints and the rules used, so
+// parsers with different extensions or rule sets never see each other's
+// results, while parsers merging the same extension values, e.g. Bots,
+// share them. Parses stopped by their Budget are not cached.
+type ResultCache struct {
+	lru[resultKey, cachedResult]
+}
+
+type cachedResult struct {
+	result IResult
+	// merges keeps the extension rules identified in the key allocated, so
+	// their addresses can't be reused by other rules while the entry lives
+	merges []ruleMerge
+}
+
+// CacheStats reports the activity of a ResultCache.
+type CacheStats struct {
+	Hits      uint64 `json:"hits"`
+	Misses    uint64 `json:"misses"`
+	Evictions uint64 `json:"evictions"`
+	// Expirations counts entries dropped because they outlived the TTL.
+	Expirations uint64 `json:"expirations"`
+	Len         int    `json:"len"`
+}
+
+type resultKey struct {
+	ua    string
+	hints string
+	rules uint64
+	ext   string
+	names uint64
+}
+
+// NewResultCache returns a cache holding up to size results. Results older
+// than ttl are parsed again; a zero ttl keeps them until they are evicted.
+func NewResultCache(size int, ttl time.Duration) *ResultCache {
+	c := &ResultCache{}
+	c.init(size, ttl)
+	return c
+}
+
+func (c *ResultCache) get(key resultKey) (IResult, bool) {
+	cached, ok := c.lru.get(key)
+	result := cached.result
+	// callers own the results they get
+	result.Unreliable = slices.Clone(result.Unreliable)
+	result.Automation.Signals = slices.Clone(result.Automation.Signals)
+	return result, ok
+}
+
+func (c *ResultCache) put(key resultKey, result IResult, merges []ruleMerge) {
+	c.lru.put(key, cachedResult{result: result, merges: merges})
+}
+
+// Stats returns the counters of the cache.
+func (c *ResultCache) Stats() CacheStats {
+	return c.stats()
+}
+
+// Purge removes every entry. The counters are kept.
+func (c *ResultCache) Purge() {
+	c.purge()
+}
+
+// lru is a size-bounded LRU cache whose entries expire after a TTL. It is
+// safe for concurrent use.
+type lru[K comparable, V any] struct {
+	mu       sync.Mutex
+	size     int
+	ttl      time.Duration
+	entries  map[K]*list.Element
+	order    *list.List // most recently used first
+	counters CacheStats
+	now      func() time.Time
+}
+
+type lruEntry[K comparable, V any] struct {
+	key     K
+	value   V
+	expires time.Time
+}
+
+func newLRU[K comparable, V any](size int, ttl time.Duration) *lru[K, V] {
+	c := &lru[K, V]{}
+	c.init(size, ttl)
+	return c
+}
+
+func (c *lru[K, V]) init(size int, ttl time.Duration) {
+	if size < 1 {
+		size = 1
+	}
+	c.size, c.ttl = size, ttl
+	c.entries = make(map[K]*list.Element, size)
+	c.order = list.New()
+	c.now = time.Now
+}
+
+func (c *lru[K, V]) get(key K) (V, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	var zero V
+	elem, ok := c.entries[key]
+	if !ok {
+		c.counters.Misses++
+		return zero, false
+	}
+	entry := elem.Value.(*lruEntry[K, V])
+	if !entry.expires.IsZero() && c.now().After(entry.expires) {
+		c.order.Remove(elem)
+		delete(c.entries, key)
+		c.counters.Expirations++
+		c.counters.Misses++
+		return zero, false
+	}
+	c.order.MoveToFront(elem)
+	c.counters.Hits++
+	return entry.value, true
+}
+
+func (c *lru[K, V]) put(key K, value V) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	var expires time.Time
+	if c.ttl > 0 {
+		expires = c.now().Add(c.ttl)
+	}
+	if elem, ok := c.entries[key]; ok {
+		entry := elem.Value.(*lruEntry[K, V])
+		entry.value, entry.expires = value, expires
+		c.order.MoveToFront(elem)
+		return
+	}
+	c.entries[key] = c.order.PushFront(&lruEntry[K, V]{key: key, value: value, expires: expires})
+	for c.order.Len() > c.size {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*lruEntry[K, V]).key)
+		c.counters.Evictions++
+	}
+}
+
+func (c *lru[K, V]) stats() CacheStats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	stats := c.counters
+	stats.Len = c.order.Len()
+	return stats
+}
+
+func (c *lru[K, V]) purge() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries = make(map[K]*list.Element, c.size)
+	c.order.Init()
+}
+
+// resultKey returns the cache key of in.
+func (p *Parser) resultKey(in *input, rules *Rules) resultKey {
+	key := resultKey{ua: in.ua, rules: rules.id}
+	if p.names != nil {
+		key.names = p.names.id
+	}
+	if len(p.merges) > 0 {
+		// merged rules are created per parser; identify them by their root
+		// and the extension values merged instead
+		key.rules = rules.root.id
+		key.ext = p.mergesKey()
+	}
+	if in.withCH {
+		key.hints = in.hints.key()
+	}
+	return key
+}
+
+// mergesKey identifies the merges of p, see extensionKey.
+func (p *Parser) mergesKey() string {
+	merges := make([]string, len(p.merges))
+	for i, m := range p.merges {
+		merges[i] = extensionKey(m.mode, m.ext)
+	}
+	return strings.Join(merges, ";")
+}
+
+// key returns the client hints in a normalized form.
+func (ch ClientHints) key() string {
+	var b strings.Builder
+	writeBrands := func(brands []IBrand) {
+		for _, brand := range brands {
+			b.WriteString(strconv.Quote(brand.Name) + "=" + strconv.Quote(brand.Version) + ",")
+		}
+		b.WriteByte('|')
+	}
+	writeBrands(ch.Brands)
+	writeBrands(ch.FullVersionList)
+	for _, s := range []string{strconv.FormatBool(ch.Mobile), ch.Model, ch.Platform, ch.PlatformVersion,
+		strings.Join(ch.FormFactors, ","), ch.Architecture, ch.Bitness, strconv.FormatBool(ch.Wow64), ch.FullVersion} {
+		b.WriteString(strconv.Quote(s) + "|")
+	}
+	return b.String()
+}
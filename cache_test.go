@@ -0,0 +1,100 @@
+package uaparser
+
+import (
+	"github.com/stretchr/testify/assert"
+	"sync"
+	"testing"
+	"time"
+)
+
+const (
+	cacheChromeUA  = "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Safari/537.36"
+	cacheFirefoxUA = "Mozilla/5.0 (X11; Linux x86_64; rv:121.0) Gecko/20100101 Firefox/121.0"
+	cacheSafariUA  = "Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/17.1 Safari/605.1.15"
+)
+
+func TestResultCache(t *testing.T) {
+	cache := NewResultCache(2, 0)
+
+	first := NewUAParser(cacheChromeUA).WithCache(cache).Result()
+	second := NewUAParser(cacheChromeUA).WithCache(cache).Result()
+	assert.Equal(t, first, second)
+	assert.Equal(t, "Chrome", second.Browser.Name)
+	assert.Equal(t, CacheStats{Hits: 1, Misses: 1, Len: 1}, cache.Stats())
+
+	NewUAParser(cacheFirefoxUA).WithCache(cache).Result()
+	NewUAParser(cacheChromeUA).WithCache(cache).Result()
+	// Firefox is now the least recently used entry
+	NewUAParser(cacheSafariUA).WithCache(cache).Result()
+	assert.Equal(t, CacheStats{Hits: 2, Misses: 3, Evictions: 1, Len: 2}, cache.Stats())
+
+	NewUAParser(cacheChromeUA).WithCache(cache).Result()
+	NewUAParser(cacheFirefoxUA).WithCache(cache).Result()
+	assert.Equal(t, CacheStats{Hits: 3, Misses: 4, Evictions: 2, Len: 2}, cache.Stats())
+
+	cache.Purge()
+	assert.Equal(t, 0, cache.Stats().Len)
+}
+
+func TestResultCache_TTL(t *testing.T) {
+	now := time.Now()
+	cache := NewResultCache(10, time.Minute)
+	cache.now = func() time.Time { return now }
+
+	NewUAParser(cacheChromeUA).WithCache(cache).Result()
+	now = now.Add(30 * time.Second)
+	NewUAParser(cacheChromeUA).WithCache(cache).Result()
+	now = now.Add(time.Minute)
+	NewUAParser(cacheChromeUA).WithCache(cache).Result()
+	assert.Equal(t, CacheStats{Hits: 1, Misses: 2, Expirations: 1, Len: 1}, cache.Stats())
+}
+
+func TestResultCache_Key(t *testing.T) {
+	cache := NewResultCache(10, 0)
+
+	plain := NewUAParser(cacheChromeUA).WithCache(cache).Result()
+	hinted := NewUAParser(cacheChromeUA).WithCache(cache).WithHeaders(map[string]string{
+		"sec-ch-ua":          `"Chromium";v="120", "Google Chrome";v="120"`,
+		"sec-ch-ua-platform": `"Android"`,
+		"sec-ch-ua-mobile":   "?1",
+	}).Result()
+	assert.NotEqual(t, plain, hinted)
+	assert.Equal(t, "Android", hinted.Os.Name)
+
+	// the same extensions share entries, even set on separate parsers, other
+	// ones don't
+	ua := "curl/8.4.0"
+	NewUAParser(ua).WithCache(cache).Result()
+	withCLIs := NewUAParser(ua).WithExtensions(CLIs).WithCache(cache).Result()
+	assert.Equal(t, "curl", withCLIs.Browser.Name)
+	assert.Equal(t, withCLIs, NewUAParser(ua).WithExtensions(CLIs).WithCache(cache).Result())
+	parsed, _ := NewParser(WithExtensions(CLIs), WithCache(cache)).Parse(ua)
+	assert.Equal(t, withCLIs, parsed)
+	NewUAParser(ua).WithExtensions(Bots).WithCache(cache).Result()
+	NewUAParser(ua).WithMerge(Append, CLIs).WithCache(cache).Result()
+	assert.Equal(t, CacheStats{Hits: 2, Misses: 6, Len: 6}, cache.Stats())
+
+	// so do rule sets
+	rules := newRules(map[string][]regexItem{UABrowser: {{
+		patterns: []string{`(?i)(chrome)\/([\w\.]+)`},
+		output:   map[string]string{Name: "Custom"},
+	}}})
+	assert.Equal(t, "Custom", NewUAParser(cacheChromeUA).WithRules(rules).WithCache(cache).Result().Browser.Name)
+}
+
+func TestResultCache_Concurrent(t *testing.T) {
+	cache := NewResultCache(2, time.Minute)
+	uas := []string{cacheChromeUA, cacheFirefoxUA, cacheSafariUA}
+	var wg sync.WaitGroup
+	for i := 0; i < 30; i++ {
+		wg.Add(1)
+		go func(ua string) {
+			defer wg.Done()
+			assert.Equal(t, NewUAParser(ua).Result(), NewUAParser(ua).WithCache(cache).Result())
+		}(uas[i%len(uas)])
+	}
+	wg.Wait()
+	stats := cache.Stats()
+	assert.Equal(t, uint64(30), stats.Hits+stats.Misses)
+	assert.LessOrEqual(t, stats.Len, 2)
+}
@@ -0,0 +1,239 @@
+package uaparser
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Anomalies reported by CheckConsistency.
+const (
+	// AnomalyEngineMismatch is a browser on an engine it doesn't use, e.g.
+	// Chrome on Gecko, or anything but WebKit on iOS.
+	AnomalyEngineMismatch = "engine-mismatch"
+	// AnomalyEngineVersionMismatch is a Chromium-based browser whose major
+	// version differs from the one of its Blink engine.
+	AnomalyEngineVersionMismatch = "engine-version-mismatch"
+	// AnomalySafariOffApple is a recent Safari on another OS than Apple's.
+	AnomalySafariOffApple = "safari-off-apple"
+	// AnomalyDeviceOSMismatch is a device that doesn't run the OS, e.g. an
+	// iPhone on Windows, or a phone on a desktop OS.
+	AnomalyDeviceOSMismatch = "device-os-mismatch"
+	// AnomalyUnexpectedHints is a browser sending Sec-CH-UA although it
+	// doesn't implement client hints, e.g. Firefox or Safari.
+	AnomalyUnexpectedHints = "unexpected-hints"
+	// AnomalyBrandMismatch is a Sec-CH-UA brand list without the browser of
+	// the UA, or with another major version.
+	AnomalyBrandMismatch = "brand-mismatch"
+	// AnomalyPlatformMismatch is a Sec-CH-UA-Platform other than the OS of
+	// the UA.
+	AnomalyPlatformMismatch = "platform-mismatch"
+	// AnomalyMobileMismatch is a Sec-CH-UA-Mobile disagreeing with the device
+	// type of the UA.
+	AnomalyMobileMismatch = "mobile-mismatch"
+)
+
+// anomalyWeights are the likelihoods of spoofing given by each anomaly.
+var anomalyWeights = map[string]float64{
+	AnomalyEngineMismatch:        0.6,
+	AnomalyEngineVersionMismatch: 0.4,
+	AnomalySafariOffApple:        0.6,
+	AnomalyDeviceOSMismatch:      0.5,
+	AnomalyUnexpectedHints:       0.7,
+	AnomalyBrandMismatch:         0.6,
+	AnomalyPlatformMismatch:      0.8,
+	AnomalyMobileMismatch:        0.5,
+}
+
+// Anomaly is an inconsistency found by CheckConsistency.
+type Anomaly struct {
+	Name   string  `json:"name"`
+	Detail string  `json:"detail"`
+	Weight float64 `json:"weight"`
+}
+
+// Consistency is the outcome of CheckConsistency.
+type Consistency struct {
+	Anomalies []Anomaly `json:"anomalies,omitempty"`
+	// Score is the suspicion of spoofing, from 0 for a consistent UA to
+	// close to 1. Each anomaly is taken as independent evidence, so the
+	// score is 1 minus the product of 1 minus their weights.
+	Score float64 `json:"score"`
+}
+
+// Has reports whether c has an anomaly called name.
+func (c Consistency) Has(name string) bool {
+	for _, anomaly := range c.Anomalies {
+		if anomaly.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+func (c *Consistency) add(name, format string, args ...any) {
+	weight := anomalyWeights[name]
+	c.Anomalies = append(c.Anomalies, Anomaly{Name: name, Detail: fmt.Sprintf(format, args...), Weight: weight})
+	c.Score = 1 - (1-c.Score)*(1-weight)
+}
+
+// CheckConsistency cross-checks the browser, engine, OS and device parsed
+// from ua with each other and with the brands, platform and mobile hint of
+// hints, if given, using the default parser.
+func CheckConsistency(ua string, hints ...ClientHints) Consistency {
+	return defaultParser.CheckConsistency(ua, hints...)
+}
+
+// CheckConsistency is CheckConsistency parsing ua with p. The UA is parsed
+// alone, since client hints would replace the values they are checked
+// against.
+func (p *Parser) CheckConsistency(ua string, hints ...ClientHints) Consistency {
+	result, _ := p.Parse(ua)
+	if len(hints) > 0 {
+		return checkConsistency(result, &hints[0])
+	}
+	return checkConsistency(result, nil)
+}
+
+// Consistency is CheckConsistency for the UA and client hints of p.
+func (p *UAParser) Consistency() Consistency {
+	if p.withCH {
+		return p.parser.CheckConsistency(p.ua, p.httpUACH)
+	}
+	return p.parser.CheckConsistency(p.ua)
+}
+
+func checkConsistency(result IResult, hints *ClientHints) Consistency {
+	var c Consistency
+	family := browserFamily(result.Browser.Name)
+	major, _ := strconv.Atoi(result.Browser.Major)
+	platform := osPlatform(result.Os.Name)
+
+	if engine := expectedEngine(family, major, platform); engine != "" && result.Engine.Name != "" &&
+		!strings.EqualFold(engine, result.Engine.Name) {
+		c.add(AnomalyEngineMismatch, "%s on %s expects %s, not %s",
+			result.Browser.Name, result.Os.Name, engine, result.Engine.Name)
+	}
+	if (family == "chrome" || family == "edge" && major >= 79) && strings.EqualFold(result.Engine.Name, "Blink") {
+		if engineMajor, _, _ := strings.Cut(result.Engine.Version, "."); engineMajor != "" && engineMajor != result.Browser.Major {
+			c.add(AnomalyEngineVersionMismatch, "%s %s on Blink %s",
+				result.Browser.Name, result.Browser.Major, result.Engine.Version)
+		}
+	}
+	// Safari for Windows stopped at 5.1
+	if family == "safari" && major > 5 && platform != "" && platform != "iOS" && platform != "macOS" {
+		c.add(AnomalySafariOffApple, "Safari %s on %s", result.Browser.Version, result.Os.Name)
+	}
+	checkDeviceOS(&c, result, platform)
+	if hints != nil {
+		checkHints(&c, result, hints, family, platform)
+	}
+	return c
+}
+
+func checkDeviceOS(c *Consistency, result IResult, platform string) {
+	switch {
+	case result.Device.Vendor == Apple && platform != "" && platform != "iOS" && platform != "macOS":
+		c.add(AnomalyDeviceOSMismatch, "%s %s on %s", Apple, result.Device.Model, result.Os.Name)
+	case platform == "iOS" && result.Device.Vendor != "" && result.Device.Vendor != Apple:
+		c.add(AnomalyDeviceOSMismatch, "%s %s on iOS", result.Device.Vendor, result.Device.Model)
+	case result.Device.Type == Mobile && (platform == Windows || platform == "macOS" || platform == "Linux" ||
+		platform == "Chrome OS" || platform == "Chromium OS"):
+		c.add(AnomalyDeviceOSMismatch, "%s device on %s", Mobile, result.Os.Name)
+	}
+}
+
+// chIncapable are the browser families not sending Sec-CH-UA. Browsers
+// outside browserFamily are given the benefit of the doubt, since most are
+// based on Chromium.
+var chIncapable = map[string]bool{"firefox": true, "safari": true}
+
+// familyBrands are the Sec-CH-UA brands sent by each browser family.
+var familyBrands = map[string][]string{
+	"chrome": {"Google Chrome", "Chromium", "HeadlessChrome"},
+	"edge":   {"Microsoft Edge"},
+	"opera":  {"Opera"},
+}
+
+func checkHints(c *Consistency, result IResult, hints *ClientHints, family, platform string) {
+	if len(hints.Brands) > 0 || len(hints.FullVersionList) > 0 {
+		switch {
+		case chIncapable[family] || platform == "iOS":
+			c.add(AnomalyUnexpectedHints, "%s on %s sent Sec-CH-UA", result.Browser.Name, result.Os.Name)
+		case familyBrands[family] != nil:
+			checkBrands(c, result, hints, family)
+		}
+		if hintMobile := hints.Mobile; hintMobile != (result.Device.Type == Mobile) {
+			c.add(AnomalyMobileMismatch, "Sec-CH-UA-Mobile %t for a %s device", hintMobile, deviceTypeOrDesktop(result.Device.Type))
+		}
+	}
+	if hints.Platform != "" && platform != "" && !strings.EqualFold(hints.Platform, platform) {
+		c.add(AnomalyPlatformMismatch, "Sec-CH-UA-Platform %q for %s", hints.Platform, result.Os.Name)
+	}
+}
+
+func checkBrands(c *Consistency, result IResult, hints *ClientHints, family string) {
+	brands := hints.FullVersionList
+	if len(brands) == 0 {
+		brands = hints.Brands
+	}
+	for _, brand := range brands {
+		for _, name := range familyBrands[family] {
+			if !strings.EqualFold(brand.Name, name) {
+				continue
+			}
+			brandMajor, _, _ := strings.Cut(brand.Version, ".")
+			if result.Browser.Major != "" && brandMajor != result.Browser.Major {
+				c.add(AnomalyBrandMismatch, "%s %s with brand %s %s",
+					result.Browser.Name, result.Browser.Major, brand.Name, brand.Version)
+			}
+			return
+		}
+	}
+	c.add(AnomalyBrandMismatch, "no brand of %s in Sec-CH-UA", result.Browser.Name)
+}
+
+func deviceTypeOrDesktop(deviceType string) string {
+	if deviceType == "" {
+		return "desktop"
+	}
+	return deviceType
+}
+
+// browserFamily returns the family of a browser name whose engine is known,
+// or "" for other browsers.
+func browserFamily(name string) string {
+	name = strings.TrimPrefix(strings.ToLower(name), "mobile ")
+	switch name {
+	case "chrome", "chrome headless", "chrome webview", "chromium":
+		return "chrome"
+	case "edge", "firefox", "safari", "opera":
+		return name
+	}
+	return ""
+}
+
+// expectedEngine returns the engine of a browser family on platform, or ""
+// if it is unknown.
+func expectedEngine(family string, major int, platform string) string {
+	if family == "" {
+		return ""
+	}
+	// every iOS browser must use WebKit
+	if platform == "iOS" {
+		return "WebKit"
+	}
+	switch {
+	case family == "chrome" && major >= 28, family == "edge" && major >= 79, family == "opera" && major >= 15:
+		return "Blink"
+	case family == "edge" && major > 0:
+		return "EdgeHTML"
+	case family == "opera" && major > 0:
+		return "Presto"
+	case family == "firefox":
+		return "Gecko"
+	case family == "safari":
+		return "WebKit"
+	}
+	return ""
+}
@@ -0,0 +1,71 @@
+package uaparser
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestCheckConsistency(t *testing.T) {
+	iPhoneUA := "Mozilla/5.0 (iPhone; CPU iPhone OS 17_0 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/17.0 Mobile/15E148 Safari/604.1"
+	chromeHints := ClientHints{
+		Brands:   []IBrand{{Name: "Not_A Brand", Version: "8"}, {Name: "Chromium", Version: "120"}, {Name: "Google Chrome", Version: "120"}},
+		Platform: "Windows",
+	}
+	tests := []struct {
+		name      string
+		ua        string
+		hints     []ClientHints
+		anomalies []string
+	}{
+		{"chrome", cacheChromeUA, []ClientHints{chromeHints}, nil},
+		{"firefox", cacheFirefoxUA, nil, nil},
+		{"safari", "Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/17.0 Safari/605.1.15", nil, nil},
+		{"iphone", iPhoneUA, nil, nil},
+		{"chrome on ios", "Mozilla/5.0 (iPhone; CPU iPhone OS 17_0 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) CriOS/120.0.6099.119 Mobile/15E148 Safari/604.1", nil, nil},
+		{"android", "Mozilla/5.0 (Linux; Android 14; SM-S918B) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Mobile Safari/537.36",
+			[]ClientHints{{Brands: []IBrand{{Name: "Google Chrome", Version: "120"}}, Mobile: true, Platform: "Android"}}, nil},
+		{"edge", "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Safari/537.36 Edg/120.0.2210.91",
+			[]ClientHints{{Brands: []IBrand{{Name: "Microsoft Edge", Version: "120"}}, Platform: "Windows"}}, nil},
+
+		{"safari on windows", "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/17.0 Safari/605.1.15", nil,
+			[]string{AnomalySafariOffApple}},
+		{"chrome on gecko", "Mozilla/5.0 (Windows NT 10.0; Win64; x64; rv:120.0) Gecko/20100101 Chrome/132.0.0.0", nil,
+			[]string{AnomalyEngineMismatch}},
+		{"iphone on windows", iPhoneUA, []ClientHints{{Platform: "Windows"}},
+			[]string{AnomalyPlatformMismatch}},
+		{"phone on windows", "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Mobile Safari/537.36", nil,
+			[]string{AnomalyDeviceOSMismatch}},
+		{"firefox with hints", cacheFirefoxUA, []ClientHints{chromeHints},
+			[]string{AnomalyUnexpectedHints, AnomalyPlatformMismatch}},
+		{"old chrome brand", "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/132.0.0.0 Safari/537.36",
+			[]ClientHints{chromeHints}, []string{AnomalyBrandMismatch}},
+		{"linux hints", cacheChromeUA, []ClientHints{{Brands: chromeHints.Brands, Mobile: true, Platform: "Linux"}},
+			[]string{AnomalyMobileMismatch, AnomalyPlatformMismatch}},
+	}
+	for _, tc := range tests {
+		c := CheckConsistency(tc.ua, tc.hints...)
+		var names []string
+		for _, anomaly := range c.Anomalies {
+			names = append(names, anomaly.Name)
+			assert.NotEmpty(t, anomaly.Detail, tc.name)
+		}
+		assert.Equal(t, tc.anomalies, names, tc.name)
+		if tc.anomalies == nil {
+			assert.Zero(t, c.Score, tc.name)
+		} else {
+			assert.Greater(t, c.Score, 0.0, tc.name)
+		}
+	}
+}
+
+func TestConsistency_Score(t *testing.T) {
+	// Safari on Windows with an Android platform hint
+	c := NewUAParser("Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/17.0 Safari/605.1.15").
+		WithHeaders(map[string]string{CHHeaderPlatform: `"Android"`}).
+		Consistency()
+	assert.True(t, c.Has(AnomalySafariOffApple))
+	assert.True(t, c.Has(AnomalyPlatformMismatch))
+	assert.False(t, c.Has(AnomalyEngineMismatch))
+	assert.InDelta(t, 1-(1-0.6)*(1-0.8), c.Score, 1e-9)
+	assert.Less(t, c.Score, 1.0)
+}
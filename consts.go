@@ -6,8 +6,10 @@ const (
 	Mobile              = "mobile"
 	Platform            = "platform"
 	Bitness             = "bitness"
+	Wow64               = "wow64"
 	CHHeader            = "sec-ch-ua"
 	CHHeaderFullVerList = CHHeader + "-full-version-list"
+	CHHeaderFullVersion = CHHeader + "-full-version"
 	CHHeaderArch        = CHHeader + "-arch"
 	CHHeaderBitness     = CHHeader + "-" + Bitness
 	CHHeaderFormFactors = CHHeader + "-form-factors"
@@ -15,6 +17,7 @@ const (
 	CHHeaderModel       = CHHeader + "-" + Model
 	CHHeaderPlatform    = CHHeader + "-" + Platform
 	CHHeaderPlatformVer = CHHeaderPlatform + "-version"
+	CHHeaderWow64       = CHHeader + "-" + Wow64
 )
 
 // for regex-base impl
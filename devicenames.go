@@ -0,0 +1,214 @@
+package uaparser
+
+import (
+	"bytes"
+	_ "embed"
+	"errors"
+	"fmt"
+	"gopkg.in/yaml.v3"
+	"io"
+	"os"
+	"slices"
+	"strings"
+	"sync/atomic"
+)
+
+// DeviceName is the marketing name of a device model code.
+type DeviceName struct {
+	Vendor string `yaml:"-" json:"vendor"`
+	// Model is the code found in UAs, e.g. SM-G991B. A trailing "*" makes it
+	// match every code it is a prefix of.
+	Model string `yaml:"model" json:"model"`
+	Name  string `yaml:"name" json:"name"`
+	Year  int    `yaml:"year,omitempty" json:"year,omitempty"` // of release, 0 if unknown
+}
+
+// DeviceNames maps device model codes to marketing names, per vendor. It is
+// never modified once created, so it can be shared by any number of
+// parsers.
+type DeviceNames struct {
+	id       uint64 // identifies the names in result cache keys
+	exact    map[string]DeviceName
+	prefixes map[string][]DeviceName // longest prefix first
+	len      int
+}
+
+//go:embed data/devices/names.yaml
+var defaultDeviceNamesYAML []byte
+
+// DefaultDeviceNames are the names embedded in the package, read from
+// data/devices/names.yaml.
+var DefaultDeviceNames = mustLoadDeviceNames(defaultDeviceNamesYAML)
+
+var deviceNamesID atomic.Uint64
+
+func mustLoadDeviceNames(data []byte) *DeviceNames {
+	names, err := LoadDeviceNames(bytes.NewReader(data))
+	if err != nil {
+		panic(err)
+	}
+	return names
+}
+
+// NewDeviceNames returns the names of entries. Later entries replace the
+// earlier ones of the same vendor and model.
+func NewDeviceNames(entries ...DeviceName) *DeviceNames {
+	d := &DeviceNames{
+		id:       deviceNamesID.Add(1),
+		exact:    make(map[string]DeviceName),
+		prefixes: make(map[string][]DeviceName),
+	}
+	for _, entry := range entries {
+		d.add(entry)
+	}
+	for vendor, prefixes := range d.prefixes {
+		slices.SortStableFunc(prefixes, func(a, b DeviceName) int {
+			return len(b.Model) - len(a.Model)
+		})
+		d.prefixes[vendor] = prefixes
+	}
+	return d
+}
+
+func (d *DeviceNames) add(entry DeviceName) {
+	vendor := strings.ToLower(entry.Vendor)
+	if prefix, ok := strings.CutSuffix(entry.Model, "*"); ok {
+		entry.Model = prefix
+		prefixes := d.prefixes[vendor]
+		i := slices.IndexFunc(prefixes, func(e DeviceName) bool { return strings.EqualFold(e.Model, prefix) })
+		if i < 0 {
+			d.prefixes[vendor] = append(prefixes, entry)
+			d.len++
+		} else {
+			prefixes[i] = entry
+		}
+		return
+	}
+	key := vendor + "\x00" + strings.ToLower(entry.Model)
+	if _, ok := d.exact[key]; !ok {
+		d.len++
+	}
+	d.exact[key] = entry
+}
+
+// With returns a copy of d with entries added, replacing the entries of d
+// with the same vendor and model.
+func (d *DeviceNames) With(entries ...DeviceName) *DeviceNames {
+	return NewDeviceNames(append(d.Entries(), entries...)...)
+}
+
+// Entries returns the entries of d. Prefix entries keep their "*".
+func (d *DeviceNames) Entries() []DeviceName {
+	entries := make([]DeviceName, 0, d.len)
+	for _, entry := range d.exact {
+		entries = append(entries, entry)
+	}
+	for _, prefixes := range d.prefixes {
+		for _, entry := range prefixes {
+			entry.Model += "*"
+			entries = append(entries, entry)
+		}
+	}
+	slices.SortFunc(entries, func(a, b DeviceName) int {
+		if c := strings.Compare(a.Vendor, b.Vendor); c != 0 {
+			return c
+		}
+		return strings.Compare(a.Model, b.Model)
+	})
+	return entries
+}
+
+// Len returns the number of entries of d.
+func (d *DeviceNames) Len() int {
+	return d.len
+}
+
+// Lookup returns the marketing name of model, a code of vendor as found in
+// IDevice. Exact model codes take precedence over prefixes, and longer
+// prefixes over shorter ones. Both are matched regardless of case.
+func (d *DeviceNames) Lookup(vendor, model string) (DeviceName, bool) {
+	if model == "" {
+		return DeviceName{}, false
+	}
+	vendor = strings.ToLower(vendor)
+	if entry, ok := d.exact[vendor+"\x00"+strings.ToLower(model)]; ok {
+		return entry, true
+	}
+	for _, entry := range d.prefixes[vendor] {
+		if len(model) >= len(entry.Model) && strings.EqualFold(model[:len(entry.Model)], entry.Model) {
+			return entry, true
+		}
+	}
+	return DeviceName{}, false
+}
+
+// enrich sets the marketing name of device, looking up its model or else
+// the device code of the in-app tokens of ua, e.g. FBDV/iPad7,11 where the
+// UA only says iPad.
+func (d *DeviceNames) enrich(device IDevice, ua string) IDevice {
+	entry, ok := d.Lookup(device.Vendor, device.Model)
+	if !ok && device.Vendor != "" {
+		if code := DetectInApp(ua).Device; code != "" {
+			entry, ok = d.Lookup(device.Vendor, code)
+		}
+	}
+	if ok {
+		device.MarketingName, device.ReleaseYear = entry.Name, entry.Year
+	}
+	return device
+}
+
+// LoadDeviceNames reads device names. The file is a YAML (or JSON)
+// document mapping vendors to their entries, see data/devices/names.yaml:
+//
+//	Samsung:
+//	  - model: SM-G991*
+//	    name: Galaxy S21 5G
+//	    year: 2021
+//
+// Every invalid entry is reported in the returned error.
+func LoadDeviceNames(r io.Reader) (*DeviceNames, error) {
+	var file map[string][]DeviceName
+	dec := yaml.NewDecoder(r)
+	dec.KnownFields(true)
+	if err := dec.Decode(&file); err != nil && !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("uaparser: decode device names: %w", err)
+	}
+
+	vendors := make([]string, 0, len(file))
+	for vendor := range file {
+		vendors = append(vendors, vendor)
+	}
+	slices.Sort(vendors)
+	var entries []DeviceName
+	var errs []error
+	for _, vendor := range vendors {
+		for i, entry := range file[vendor] {
+			entry.Vendor = vendor
+			switch {
+			case entry.Model == "" || entry.Model == "*":
+				errs = append(errs, fmt.Errorf("%s[%d]: missing model", vendor, i))
+			case entry.Name == "":
+				errs = append(errs, fmt.Errorf("%s[%d] %s: missing name", vendor, i, entry.Model))
+			default:
+				entries = append(entries, entry)
+			}
+		}
+	}
+	if err := errors.Join(errs...); err != nil {
+		return nil, err
+	}
+	return NewDeviceNames(entries...), nil
+}
+
+// LoadDeviceNamesFile reads device names from a YAML or JSON file.
+func LoadDeviceNamesFile(path string) (*DeviceNames, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer func(f *os.File) {
+		_ = f.Close()
+	}(f)
+	return LoadDeviceNames(f)
+}
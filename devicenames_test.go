@@ -0,0 +1,87 @@
+package uaparser
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestDeviceNames_Lookup(t *testing.T) {
+	tests := []struct {
+		vendor, model string
+		name          string
+		year          int
+	}{
+		{"Samsung", "SM-G991B", "Galaxy S21 5G", 2021},
+		{"samsung", "sm-g991u1", "Galaxy S21 5G", 2021},
+		{"Xiaomi", "M2101K6G", "Redmi Note 10 Pro", 2021},
+		{"Apple", "iPad7,11", "iPad (7th generation)", 2019},
+		{"Huawei", "ELS-NX9", "P40 Pro", 2020},
+		{"OPPO", "CPH2173", "Find X3 Pro", 2021},
+		{"Samsung", "SM-G99", "", 0},
+		{"Xiaomi", "SM-G991B", "", 0},
+		{"Apple", "iPad", "", 0},
+		{"Apple", "", "", 0},
+	}
+	for _, tc := range tests {
+		entry, ok := DefaultDeviceNames.Lookup(tc.vendor, tc.model)
+		assert.Equal(t, tc.name != "", ok, tc.model)
+		assert.Equal(t, tc.name, entry.Name, tc.model)
+		assert.Equal(t, tc.year, entry.Year, tc.model)
+	}
+
+	// exact codes and longer prefixes win
+	names := DefaultDeviceNames.With(
+		DeviceName{Vendor: "Samsung", Model: "SM-G991N", Name: "Galaxy S21 5G (Korea)"},
+		DeviceName{Vendor: "Samsung", Model: "SM-G9910*", Name: "Galaxy S21 5G (China)"},
+	)
+	assert.Equal(t, DefaultDeviceNames.Len()+2, names.Len())
+	for model, name := range map[string]string{
+		"SM-G991N": "Galaxy S21 5G (Korea)", "SM-G9910": "Galaxy S21 5G (China)", "SM-G991B": "Galaxy S21 5G",
+	} {
+		entry, _ := names.Lookup("Samsung", model)
+		assert.Equal(t, name, entry.Name, model)
+	}
+	entry, _ := DefaultDeviceNames.Lookup("Samsung", "SM-G991N")
+	assert.Equal(t, "Galaxy S21 5G", entry.Name)
+}
+
+func TestLoadDeviceNames(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "names.json")
+	require.NoError(t, os.WriteFile(path, []byte(`{"Acme": [{"model": "AC-1*", "name": "Rocket", "year": 1949}]}`), 0o644))
+	names, err := LoadDeviceNamesFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, []DeviceName{{Vendor: "Acme", Model: "AC-1*", Name: "Rocket", Year: 1949}}, names.Entries())
+
+	_, err = LoadDeviceNames(strings.NewReader("Acme:\n  - {model: AC-1}\n  - {name: Rocket}\n"))
+	assert.ErrorContains(t, err, "Acme[0] AC-1: missing name")
+	assert.ErrorContains(t, err, "Acme[1]: missing model")
+	_, err = LoadDeviceNames(strings.NewReader("Acme:\n  - {model: AC-1, name: Rocket, color: red}\n"))
+	assert.Error(t, err)
+}
+
+func TestUAParser_WithDeviceNames(t *testing.T) {
+	ua := "Mozilla/5.0 (Linux; Android 12; SM-G991B) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Mobile Safari/537.36"
+	assert.Empty(t, NewUAParser(ua).Device().MarketingName)
+
+	device := NewUAParser(ua).WithDeviceNames(DefaultDeviceNames).Device()
+	assert.Equal(t, IDevice{
+		Type: Mobile, Model: "SM-G991B", Vendor: "Samsung", MarketingName: "Galaxy S21 5G", ReleaseYear: 2021,
+	}, device)
+
+	// the UA only says iPad, the Facebook tokens tell which one
+	fbUA := "Mozilla/5.0 (iPad; CPU OS 14_4_2 like Mac OS X) WebKit/8610 (KHTML, like Gecko) Mobile/18D70 [FBAN/FBIOS;FBDV/iPad7,11;FBMD/iPad;FBSN/iOS;FBSV/14.4.2;FBSS/2;FBID/tablet;FBLC/en_US;FBOP/5]"
+	device = NewUAParser(fbUA).WithDeviceNames(DefaultDeviceNames).Result().Device
+	assert.Equal(t, "iPad", device.Model)
+	assert.Equal(t, "iPad (7th generation)", device.MarketingName)
+
+	// parsers with and without names don't share cached results
+	cache := NewResultCache(16, 0)
+	plain, _ := NewParser(WithCache(cache)).Parse(ua)
+	named, _ := NewParser(WithCache(cache), WithDeviceNames(DefaultDeviceNames)).Parse(ua)
+	assert.Empty(t, plain.Device.MarketingName)
+	assert.Equal(t, "Galaxy S21 5G", named.Device.MarketingName)
+}
@@ -0,0 +1,106 @@
+package uaparser
+
+import "sort"
+
+// Sources of an explained component.
+const (
+	SourceUA          = "ua"
+	SourceClientHints = "client-hints"
+	SourceMixed       = "ua+client-hints"
+)
+
+// Explanation describes how one component of a result was obtained.
+type Explanation struct {
+	// Source is SourceUA when the result comes from a rule matching the
+	// User-Agent string, SourceClientHints when it comes from client hints
+	// only and SourceMixed when client hints overrode parts of a UA match.
+	// It is empty when nothing was detected.
+	Source string `json:"source,omitempty"`
+	// RuleIndex is the position of the matching rule in the component's rule
+	// list, with extension rules first. It is -1 if no rule matched.
+	RuleIndex int `json:"ruleIndex"`
+	// Extension reports whether the matching rule came from an extension.
+	Extension bool `json:"extension,omitempty"`
+	// PatternIndex is the position of the matching pattern within the rule.
+	PatternIndex int    `json:"patternIndex"`
+	Pattern      string `json:"pattern,omitempty"`
+	// Groups holds the whole match followed by the capture groups.
+	Groups []string `json:"groups,omitempty"`
+	// Overridden lists the fields changed by client hints.
+	Overridden []string `json:"overridden,omitempty"`
+}
+
+// ExplainResult is a parse result together with the explanation of each of
+// its components.
+type ExplainResult struct {
+	Result  IResult     `json:"result"`
+	Browser Explanation `json:"browser"`
+	Cpu     Explanation `json:"cpu"`
+	Device  Explanation `json:"device"`
+	Engine  Explanation `json:"engine"`
+	Os      Explanation `json:"os"`
+}
+
+// Explain parses like Result and additionally reports, for every component,
+// which rule matched and whether client hints changed the outcome. It is
+// meant for debugging misdetections and bypasses the result cache, so its
+// Result is always parsed anew.
+func (p *UAParser) Explain() ExplainResult {
+	in := &input{ua: p.ua, hints: p.httpUACH, withCH: p.withCH, trace: make(map[string]Explanation)}
+	result, _ := p.parser.parseAll(in, p.parser.rules())
+	traced := func(component string) Explanation {
+		if ex, ok := in.trace[component]; ok {
+			return ex
+		}
+		return Explanation{RuleIndex: -1, PatternIndex: -1}
+	}
+	return ExplainResult{
+		Result:  result,
+		Browser: traced(UABrowser),
+		Cpu:     traced(UACpu),
+		Device:  traced(UADevice),
+		Engine:  traced(UAEngine),
+		Os:      traced(UAOS),
+	}
+}
+
+// explain describes how a component was parsed: the rule matched by the UA,
+// if any, and the fields client hints changed from before to after.
+func explain(rules *Rules, itemType string, m *ruleMatch, before, after map[string]string) Explanation {
+	ex := Explanation{RuleIndex: -1, PatternIndex: -1}
+	if m != nil {
+		ex.Source = SourceUA
+		ex.RuleIndex = m.rule
+		ex.PatternIndex = m.pattern
+		ex.Pattern = rules.items[itemType][m.rule].patterns[m.pattern]
+		ex.Groups = m.groups
+		ex.Extension = rules.merged(itemType, m.rule)
+	}
+	if before != nil {
+		ex.Overridden = changedFields(before, after)
+		if len(ex.Overridden) > 0 {
+			if ex.Source == SourceUA {
+				ex.Source = SourceMixed
+			} else {
+				ex.Source = SourceClientHints
+			}
+		}
+	}
+	return ex
+}
+
+func changedFields(before, after map[string]string) []string {
+	var fields []string
+	for key, value := range after {
+		if before[key] != value {
+			fields = append(fields, key)
+		}
+	}
+	for key, value := range before {
+		if _, ok := after[key]; !ok && value != "" {
+			fields = append(fields, key)
+		}
+	}
+	sort.Strings(fields)
+	return fields
+}
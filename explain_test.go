@@ -0,0 +1,85 @@
+package uaparser
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestUAParser_Explain(t *testing.T) {
+	ua := "Mozilla/5.0 (Linux; Android 14; SM-S918B) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/121.0.6167.143 Mobile Safari/537.36"
+	parser := NewUAParser(ua)
+	ex := parser.Explain()
+
+	assert.Equal(t, parser.Result(), ex.Result)
+
+	assert.Equal(t, SourceUA, ex.Device.Source)
+	assert.Equal(t, []string{"SM-S918B"}, ex.Device.Groups[1:])
+	assert.Equal(t, regexMap[UADevice][ex.Device.RuleIndex].patterns[ex.Device.PatternIndex], ex.Device.Pattern)
+	assert.Contains(t, ex.Device.Pattern, "sm")
+	assert.False(t, ex.Device.Extension)
+	assert.Empty(t, ex.Device.Overridden)
+
+	assert.Equal(t, SourceUA, ex.Browser.Source)
+	assert.Equal(t, "121.0.6167.143", ex.Browser.Groups[1])
+
+	// nothing detected
+	assert.Equal(t, Explanation{RuleIndex: -1, PatternIndex: -1}, ex.Cpu)
+}
+
+func TestUAParser_ExplainClientHints(t *testing.T) {
+	headers := map[string]string{
+		"sec-ch-ua":                  `"Google Chrome";v="121", "Chromium";v="121", "Not A(Brand";v="99"`,
+		"sec-ch-ua-platform":         `"Android"`,
+		"sec-ch-ua-platform-version": `"14.0.0"`,
+		"sec-ch-ua-model":            `"Pixel 8"`,
+		"sec-ch-ua-mobile":           "?1",
+	}
+	ua := "Mozilla/5.0 (Linux; Android 10; K) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/121.0.0.0 Mobile Safari/537.36"
+	ex := NewUAParser(ua).WithHeaders(headers).Explain()
+
+	assert.Equal(t, SourceMixed, ex.Os.Source)
+	assert.Equal(t, []string{Version}, ex.Os.Overridden)
+	assert.Equal(t, "14.0.0", ex.Result.Os.Version)
+
+	assert.Equal(t, SourceMixed, ex.Device.Source)
+	assert.Equal(t, []string{Model, Vendor}, ex.Device.Overridden)
+	assert.Equal(t, "Pixel 8", ex.Result.Device.Model)
+
+	onlyCH := NewUAParser("").WithHeaders(headers).Explain()
+	assert.Equal(t, SourceClientHints, onlyCH.Os.Source)
+	assert.Equal(t, -1, onlyCH.Os.RuleIndex)
+}
+
+func TestUAParser_ExplainExtension(t *testing.T) {
+	ex := NewUAParser("Wget/1.21.1").WithExtensions(CLIs).Explain()
+	assert.True(t, ex.Browser.Extension)
+	assert.Equal(t, 0, ex.Browser.RuleIndex)
+	assert.Equal(t, []string{"Wget/1.21.1", "Wget", "1.21.1"}, ex.Browser.Groups)
+}
+
+// Explain reports the result parsing does, reduced fields, automation, in-app
+// and marketing names included.
+func TestUAParser_ExplainResult(t *testing.T) {
+	for _, path := range []string{"data/ua/browser", "data/ua/cpu", "data/ua/device", "data/ua/engine", "data/ua/os", "data/ua/extension"} {
+		for _, tc := range loadJson(path) {
+			parser := NewUAParser(tc.Ua).WithDeviceNames(DefaultDeviceNames)
+			assert.Equal(t, parser.Result(), parser.Explain().Result, tc.Ua)
+		}
+	}
+
+	headers := map[string]string{
+		"sec-ch-ua":          `"Google Chrome";v="120", "Chromium";v="120"`,
+		"sec-ch-ua-platform": `"Android"`,
+		"sec-ch-ua-model":    `"SM-G991B"`,
+	}
+	parser := NewUAParser(cacheChromeUA).WithHeaders(headers).WithDeviceNames(DefaultDeviceNames)
+	ex := parser.Explain()
+	assert.Equal(t, parser.Result(), ex.Result)
+	assert.Equal(t, "Galaxy S21 5G", ex.Result.Device.MarketingName)
+	assert.Equal(t, []string{SignalMissingGrease}, ex.Result.Automation.Signals)
+
+	// the budget applies as well
+	parser = NewUAParser(cacheChromeUA).WithBudget(Budget{Parse: time.Nanosecond})
+	assert.Equal(t, parser.Result(), parser.Explain().Result)
+}
@@ -3,7 +3,7 @@ package uaparser
 import "slices"
 
 var (
-	CLIs = map[string][]regexItem{
+	CLIs = Extension{
 		"browser": {
 			{
 				// wget / curl / Lynx / ELinks / HTTPie
@@ -17,7 +17,7 @@ var (
 		},
 	}
 
-	Crawlers = map[string][]regexItem{
+	Crawlers = Extension{
 		"browser": {
 			{
 				// AhrefsBot, Amazonbot, Bingbot, CCBot, Dotbot, DuckDuckBot, FacebookBot, GPTBot, MJ12bot, MojeekBot, OpenAI's SearchGPT, PerplexityBot, SeznamBot
@@ -164,7 +164,7 @@ var (
 		},
 	}
 
-	ExtraDevices = map[string][]regexItem{
+	ExtraDevices = Extension{
 		"device": {
 			{
 				// Nook
@@ -412,7 +412,7 @@ var (
 		},
 	}
 
-	Emails = map[string][]regexItem{
+	Emails = Extension{
 		"browser": {
 			{
 				// Evolution, Kontact/KMail, [Microsoft/Mac] Outlook, Thunderbird
@@ -426,7 +426,7 @@ var (
 		},
 	}
 
-	Fetchers = map[string][]regexItem{
+	Fetchers = Extension{
 		"browser": {
 			{
 				// AhrefsSiteAudit - https://ahrefs.com/robot/site-audit
@@ -480,7 +480,7 @@ var (
 		},
 	}
 
-	InApps = map[string][]regexItem{
+	InApps = Extension{
 		"browser": {
 			{
 				// Slack
@@ -503,7 +503,7 @@ var (
 		},
 	}
 
-	MediaPlayers = map[string][]regexItem{
+	MediaPlayers = Extension{
 		"browser": {
 			{
 				// Generic Apple CoreMedia
@@ -678,7 +678,7 @@ var (
 		},
 	}
 
-	Libraries = map[string][]regexItem{
+	Libraries = Extension{
 		"browser": {
 			{
 				// Apache-HttpClient/Axios/go-http-client/got/GuzzleHttp/Java[-HttpClient]/jsdom/libwww-perl/lua-resty-http/Needle/node-fetch/OkHttp/PHP-SOAP/PostmanRuntime/python-urllib/python-requests/Scrapy/superagent
@@ -693,7 +693,7 @@ var (
 		},
 	}
 
-	Vehicles = map[string][]regexItem{
+	Vehicles = Extension{
 		"device": {
 			{
 				// BYD
@@ -720,7 +720,7 @@ var (
 		},
 	}
 
-	Bots = map[string][]regexItem{
+	Bots = Extension{
 		"browser": slices.Concat(
 			CLIs["browser"],
 			Crawlers["browser"],
@@ -3,6 +3,7 @@ package uaparser
 import (
 	"github.com/stretchr/testify/assert"
 	"reflect"
+	"slices"
 	"testing"
 )
 
@@ -11,7 +12,7 @@ func TestMergeExtension(t *testing.T) {
 	facebookBot := "Mozilla/5.0 (compatible; FacebookBot/1.0; +https://developers.facebook.com/docs/sharing/webmasters/facebookbot/)"
 
 	crawlersAndCLIs := map[string][]regexItem{
-		"browser": append(Crawlers["browser"], CLIs["browser"]...),
+		"browser": slices.Concat(Crawlers["browser"], CLIs["browser"]),
 	}
 	crawlersAndCLIsParser := NewUAParser("").WithExtensions(crawlersAndCLIs)
 	assert.Equal(t, IBrowser{Name: "Wget", Version: "1.21.1", Major: "1", Type: "cli"}, crawlersAndCLIsParser.WithUA(wget).Browser())
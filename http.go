@@ -0,0 +1,62 @@
+package uaparser
+
+import (
+	"net/http"
+	"strings"
+)
+
+// listHeaders are the client hint headers holding a list, whose repeated
+// field lines are combined into one value.
+var listHeaders = map[string]bool{
+	CHHeader:            true,
+	CHHeaderFullVerList: true,
+	CHHeaderFormFactors: true,
+}
+
+// headersFromHTTP returns the User-Agent and Sec-CH-UA-* headers of h keyed
+// by their lowercased names, as WithHeaders expects them. Repeated lines of
+// a list header are joined with ", " as RFC 9110 section 5.3 allows; other
+// headers hold a single value, so only their first line is used.
+func headersFromHTTP(h http.Header) map[string]string {
+	headers := make(map[string]string)
+	for key, values := range h {
+		key = strings.ToLower(key)
+		if len(values) == 0 || key != UserAgent && !strings.HasPrefix(key, CHHeader) {
+			continue
+		}
+		value := values[0]
+		if listHeaders[key] {
+			value = strings.Join(values, ", ")
+		}
+		headers[key] = value
+	}
+	return headers
+}
+
+// WithHTTPHeader reads the User-Agent and client hints from h, e.g. the
+// Header of an *http.Request. Unlike WithHeaders it copes with repeated
+// header lines.
+func (p *UAParser) WithHTTPHeader(h http.Header) *UAParser {
+	return p.WithHeaders(headersFromHTTP(h))
+}
+
+// ParseRequest parses the User-Agent and client hint headers of r.
+func (p *Parser) ParseRequest(r *http.Request) (IResult, error) {
+	return p.ParseHeader(r.Header)
+}
+
+// ParseHeader parses the User-Agent and client hint headers of h.
+func (p *Parser) ParseHeader(h http.Header) (IResult, error) {
+	headers := headersFromHTTP(h)
+	in := &input{ua: checkUA(headers[UserAgent])}
+	if len(headers) > 0 {
+		in.hints, in.withCH = NewClientHints(headers), true
+	}
+	return p.parse(in)
+}
+
+// ParseRequest parses the User-Agent and client hint headers of r with the
+// embedded rules.
+func ParseRequest(r *http.Request) (IResult, error) {
+	return defaultParser.ParseRequest(r)
+}
@@ -0,0 +1,41 @@
+package uaparser
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestHeadersFromHTTP(t *testing.T) {
+	req := httptest.NewRequest("GET", "/", nil)
+	req.Header.Set("User-Agent", cacheChromeUA)
+	req.Header.Add("Sec-CH-UA", `"Chromium";v="120"`)
+	req.Header.Add("Sec-CH-UA", `"Google Chrome";v="120", "Not?A_Brand";v="8"`)
+	req.Header.Add("Sec-CH-UA-Platform", `"Android"`)
+	req.Header.Add("Sec-CH-UA-Platform", `"Windows"`)
+	req.Header.Set("Sec-CH-UA-Mobile", "?1")
+	req.Header.Set("Accept", "text/html")
+
+	assert.Equal(t, map[string]string{
+		UserAgent:        cacheChromeUA,
+		CHHeader:         `"Chromium";v="120", "Google Chrome";v="120", "Not?A_Brand";v="8"`,
+		CHHeaderPlatform: `"Android"`,
+		CHHeaderMobile:   "?1",
+	}, headersFromHTTP(req.Header))
+
+	result, err := ParseRequest(req)
+	require.NoError(t, err)
+	assert.Equal(t, cacheChromeUA, result.UA)
+	assert.Equal(t, "Chrome", result.Browser.Name)
+	assert.Equal(t, "Android", result.Os.Name)
+	assert.Equal(t, "mobile", result.Device.Type)
+	assert.Equal(t, result, NewUAParser("").WithHTTPHeader(req.Header).Result())
+
+	// without client hints the UA alone is parsed
+	req = httptest.NewRequest("GET", "/", nil)
+	req.Header.Set("User-Agent", cacheFirefoxUA)
+	result, err = NewParser().ParseRequest(req)
+	require.NoError(t, err)
+	assert.Equal(t, NewUAParser(cacheFirefoxUA).Result(), result)
+}
@@ -0,0 +1,147 @@
+package uaparser
+
+import (
+	"regexp"
+	"strings"
+)
+
+// WebViews reported by IInApp.WebView.
+const (
+	WebViewAndroid = "Android WebView"
+	WebViewWK      = "WKWebView"
+)
+
+var (
+	// androidWebViewReg matches Android WebViews, flagged by "; wv)" since
+	// Lollipop and by a Version/4.0 next to Chrome before. Other versions
+	// next to Chrome belong to browsers, e.g. Samsung Internet.
+	androidWebViewReg = regexp.MustCompile(`Android.*(?:; wv\)|Version/4\.0 Chrome/)`)
+	// iOSWebViewReg matches iOS devices; their WKWebViews leave out the
+	// Safari token of Safari and the other iOS browsers.
+	iOSWebViewReg = regexp.MustCompile(`\((?:iPhone|iPad|iPod)[;,].*WebKit/[\d.]+.*Mobile/\w+`)
+	// fbReg matches the Facebook tokens, e.g.
+	// [FBAN/FBIOS;FBDV/iPhone12,5;FBMD/iPhone;FBSN/iOS;FBSV/13.3.1;FBLC/en_US]
+	fbReg        = regexp.MustCompile(`\b(?:FBAN|FB_IAB)/[^\]]*`)
+	netTypeReg   = regexp.MustCompile(`\bNetType/(\w+)`)
+	uaLocaleReg  = regexp.MustCompile(`\b(?:Language|ByteLocale)/([\w-]+)`)
+	instaInfoReg = regexp.MustCompile(`\bInstagram [\d.]+ .*?; ([a-z]{2}_[A-Z]{2});`)
+)
+
+// fbApps maps the FBAN and FB_IAB codes to app names.
+var fbApps = map[string]string{
+	"FBIOS": Facebook, "FB4A": Facebook,
+	"MessengerForiOS": "Messenger", "MessengerLiteForiOS": "Messenger", "Orca-Android": "Messenger",
+}
+
+type hostApp struct {
+	name  string
+	token string         // lowercased, in every UA reg matches
+	reg   *regexp.Regexp // the version, if any, in group 1
+}
+
+// hostApps are the apps told by their own tokens, tried in order. Their
+// regexps only run on UAs containing the token, which is much cheaper to
+// look for.
+var hostApps = []hostApp{
+	{"Instagram", "instagram ", regexp.MustCompile(`\bInstagram ([\d.]+)`)},
+	{"TikTok", "musical_ly", regexp.MustCompile(`(?i)musical_ly(?:.+app_?Version/|_)([\d.]+)`)},
+	{"TikTok", "trill_", regexp.MustCompile(`(?i)\btrill_\d+\b(?:.+app_?Version/([\d.]+))?`)},
+	{"TikTok", "bytedancewebview", regexp.MustCompile(`(?i)\bBytedanceWebview\b(?:.+app_?Version/([\d.]+))?`)},
+	{"WeChat", "micromessenger/", regexp.MustCompile(`\bMicroMessenger/([\d.]+)`)},
+	{"LINE", "line/", regexp.MustCompile(`\bLine/([\d.]+)`)},
+	{"Weibo", "__weibo__", regexp.MustCompile(`__weibo__([\d.]+)__`)},
+	{"Snapchat", "snapchat/", regexp.MustCompile(`\bSnapchat/([\d.]+)`)},
+	{"KakaoTalk", "kakaotalk", regexp.MustCompile(`(?i)\bkakaotalk[/ ]([\d.]+)`)},
+	{"Twitter", "twitter", regexp.MustCompile(`\bTwitter for iP(?:hone|ad)|\bTwitterAndroid\b`)},
+	{"LinkedIn", "[linkedinapp]", regexp.MustCompile(`\[LinkedInApp\]`)},
+	{"Google", "gsa/", regexp.MustCompile(`\bGSA/([\d.]+)`)},
+	{"Alipay", "alipayclient/", regexp.MustCompile(`\bAlipayClient/([\d.]+)`)},
+	{"Slack", "chatlyio/", regexp.MustCompile(`\bchatlyio/([\d.]+)`)},
+}
+
+// DetectInApp tells whether ua comes from a WebView, and which app hosts
+// it.
+func DetectInApp(ua string) IInApp {
+	var a IInApp
+	switch {
+	case androidWebViewReg.MatchString(ua):
+		a.WebView = WebViewAndroid
+	case iOSWebViewReg.MatchString(ua) && !strings.Contains(ua, "Safari/"):
+		a.WebView = WebViewWK
+	}
+
+	if fb := findFB(ua); fb != "" {
+		parseFBTokens(&a, fb)
+	} else {
+		lower := strings.ToLower(ua)
+		for _, app := range hostApps {
+			if !strings.Contains(lower, app.token) {
+				continue
+			}
+			if m := app.reg.FindStringSubmatch(ua); m != nil {
+				a.App = app.name
+				if len(m) > 1 {
+					a.AppVersion = strings.TrimRight(m[1], ".")
+				}
+				break
+			}
+		}
+	}
+	if a.App == "" {
+		return a
+	}
+
+	if m := netTypeReg.FindStringSubmatch(ua); m != nil {
+		a.Network = m[1]
+	}
+	if a.Locale == "" {
+		if m := uaLocaleReg.FindStringSubmatch(ua); m != nil {
+			a.Locale = m[1]
+		} else if m := instaInfoReg.FindStringSubmatch(ua); m != nil {
+			a.Locale = m[1]
+		}
+	}
+	return a
+}
+
+// findFB returns the Facebook tokens of ua, if any.
+func findFB(ua string) string {
+	if !strings.Contains(ua, "FBAN/") && !strings.Contains(ua, "FB_IAB/") {
+		return ""
+	}
+	return fbReg.FindString(ua)
+}
+
+// parseFBTokens reads the KEY/value tokens of the Facebook apps, separated
+// by semicolons.
+func parseFBTokens(a *IInApp, tokens string) {
+	for _, token := range strings.Split(tokens, ";") {
+		key, value, ok := strings.Cut(strings.TrimSpace(token), "/")
+		if !ok || value == "" {
+			continue
+		}
+		switch key {
+		case "FBAN", "FB_IAB":
+			a.App = value
+			if name, ok := fbApps[value]; ok {
+				a.App = name
+			}
+		case "FBAV":
+			a.AppVersion = value
+		case "FBBV":
+			a.AppBuild = value
+		case "FBDV":
+			a.Device = value
+		case "FBSN":
+			a.System = value
+		case "FBSV":
+			a.SystemVersion = value
+		case "FBID":
+			a.FormFactor = value
+		case "FBLC":
+			a.Locale = value
+		case "FBCR":
+			a.Carrier = value
+		}
+	}
+}
@@ -0,0 +1,53 @@
+package uaparser
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestDetectInApp(t *testing.T) {
+	tests := []struct {
+		ua       string
+		expected IInApp
+	}{
+		{"Mozilla/5.0 (iPhone; CPU iPhone OS 13_3_1 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) Mobile/15E148 [FBAN/FBIOS;FBDV/iPhone12,5;FBMD/iPhone;FBSN/iOS;FBSV/13.3.1;FBSS/3;FBID/phone;FBLC/en_US;FBOP/5;FBCR/]", IInApp{
+			WebView: WebViewWK, App: Facebook, Device: "iPhone12,5", System: "iOS", SystemVersion: "13.3.1", FormFactor: "phone", Locale: "en_US",
+		}},
+		{"Mozilla/5.0 (iPhone; CPU iPhone OS 17_1 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) Mobile/15E148 [FBAN/FBIOS;FBAV/442.0.0.48.109;FBBV/547325409;FBDV/iPhone14,5;FBMD/iPhone;FBSN/iOS;FBSV/17.1;FBSS/3;FBID/phone;FBLC/de_DE;FBOP/5;FBCR/Telekom.de]", IInApp{
+			WebView: WebViewWK, App: Facebook, AppVersion: "442.0.0.48.109", AppBuild: "547325409", Device: "iPhone14,5",
+			System: "iOS", SystemVersion: "17.1", FormFactor: "phone", Locale: "de_DE", Carrier: "Telekom.de",
+		}},
+		{"Mozilla/5.0 (Linux; Android 7.0; KOB-W09 Build/HUAWEIKOB-W09; wv) AppleWebKit/537.36 (KHTML, like Gecko) Version/4.0 Chrome/69.0.3497.100 Safari/537.36 [FB_IAB/Orca-Android;FBAV/354.0.0.10.113;]", IInApp{
+			WebView: WebViewAndroid, App: "Messenger", AppVersion: "354.0.0.10.113",
+		}},
+		{"Mozilla/5.0 (iPhone; CPU iPhone OS 17_1 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) Mobile/15E148 Instagram 312.0.0.32.112 (iPhone14,5; iOS 17_1; en_US; en; scale=3.00; 1170x2532; 548335212)", IInApp{
+			WebView: WebViewWK, App: "Instagram", AppVersion: "312.0.0.32.112", Locale: "en_US",
+		}},
+		{"Mozilla/5.0 (Linux; Android 8.0; GEM-703L Build/HUAWEIGEM-703L; wv) AppleWebKit/537.36 (KHTML, like Gecko) Version/4.0 Chrome/57.0.2987.132 MQQBrowser/6.2 TBS/043906 Mobile Safari/537.36 MicroMessenger/6.6.3.1260(0x26060339) NetType/WIFI Language/zh_CN", IInApp{
+			WebView: WebViewAndroid, App: "WeChat", AppVersion: "6.6.3.1260", Locale: "zh_CN", Network: "WIFI",
+		}},
+		{"Mozilla/5.0 (Linux; Android 12; SM-A525F Build/SP1A.210812.016; wv) AppleWebKit/537.36 (KHTML, like Gecko) Version/4.0 Chrome/119.0.6045.193 Mobile Safari/537.36 trill_320104 JsSdk/1.0 NetType/4G Channel/googleplay AppName/trill app_version/32.1.4 ByteLocale/en ByteFullLocale/en Region/US BytedanceWebview/d8a21c6", IInApp{
+			WebView: WebViewAndroid, App: "TikTok", AppVersion: "32.1.4", Locale: "en", Network: "4G",
+		}},
+		{"Mozilla/5.0 (Linux; Android 9; CMR-W09 Build/HUAWEICMR-W09; wv) AppleWebKit/537.36 (KHTML, like Gecko) Version/4.0 Chrome/130.0.6723.102 Safari/537.36 Line/14.18.1/IAB", IInApp{
+			WebView: WebViewAndroid, App: "LINE", AppVersion: "14.18.1",
+		}},
+		{"Mozilla/5.0 (Linux; Android 11; S62 Pro Build/RKQ1.210406.002; wv) AppleWebKit/537.36 (KHTML, like Gecko) Version/4.0 Chrome/94.0.4606.85 Mobile Safari/537.36 GSA/12.34.17.23.arm64", IInApp{
+			WebView: WebViewAndroid, App: "Google", AppVersion: "12.34.17.23",
+		}},
+		// a WebView of an app without a token of its own
+		{"Mozilla/5.0 (iPhone; CPU iPhone OS 17_0 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) Mobile/15E148", IInApp{WebView: WebViewWK}},
+		// browsers
+		{"Mozilla/5.0 (iPhone; CPU iPhone OS 17_0 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/17.0 Mobile/15E148 Safari/604.1", IInApp{}},
+		{"Mozilla/5.0 (iPhone; CPU iPhone OS 17_0 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) CriOS/120.0.6099.119 Mobile/15E148 Safari/604.1", IInApp{}},
+		{"Mozilla/5.0 (Linux; Android 14; SM-S918B) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Mobile Safari/537.36", IInApp{}},
+		{"Mozilla/5.0 (Linux; Android 4.4.2; SAMSUNG SM-G900F Build/KOT49H) AppleWebKit/537.36 (KHTML, like Gecko) Version/1.5 Chrome/28.0.1500.94 Mobile Safari/537.36", IInApp{}},
+		{cacheChromeUA, IInApp{}},
+	}
+	for _, tc := range tests {
+		assert.Equal(t, tc.expected, DetectInApp(tc.ua), tc.ua)
+	}
+
+	result := NewUAParser(tests[2].ua).Result()
+	assert.Equal(t, tests[2].expected, result.InApp)
+}
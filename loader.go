@@ -0,0 +1,194 @@
+package uaparser
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"gopkg.in/yaml.v3"
+	"io"
+	"os"
+	"strings"
+	"sync"
+)
+
+type ruleFile map[string][]ruleSpec
+
+type ruleSpec struct {
+	Patterns []string          `yaml:"patterns"`
+	Output   map[string]string `yaml:"output"`
+	Mappers  []mapperSpec      `yaml:"mappers,omitempty"`
+}
+
+type mapperSpec struct {
+	Field string              `yaml:"field"`
+	Func  string              `yaml:"func"`
+	Args  []string            `yaml:"args,omitempty"`
+	Table map[string][]string `yaml:"table,omitempty"`
+}
+
+// MapperFactory creates a mapper function from the args and table given in
+// a rule file.
+type MapperFactory func(args []string, table map[string][]string) (func(string) string, error)
+
+var ErrUnknownMapper = errors.New("uaparser: unknown mapper")
+
+var (
+	mapperMu       sync.RWMutex
+	mapperRegistry = map[string]MapperFactory{
+		"lower": staticMapper(strings.ToLower),
+		"upper": staticMapper(strings.ToUpper),
+		"trim":  staticMapper(strings.TrimSpace),
+		"replace": func(args []string, _ map[string][]string) (func(string) string, error) {
+			if len(args) == 0 || len(args)%2 != 0 {
+				return nil, fmt.Errorf("replace needs pairs of args, got %d", len(args))
+			}
+			return strings.NewReplacer(args...).Replace, nil
+		},
+		"strip_non_numeric": staticMapper(func(s string) string {
+			return NonNumericSequenceReg.ReplaceAllString(s, "")
+		}),
+		"lookup": func(_ []string, table map[string][]string) (func(string) string, error) {
+			if len(table) == 0 {
+				return nil, errors.New("lookup needs a table")
+			}
+			if def, ok := table["*"]; ok && len(def) == 0 {
+				return nil, errors.New(`lookup default "*" needs a value`)
+			}
+			return func(s string) string { return strMapper(s, table) }, nil
+		},
+		"windows_version": staticMapper(func(s string) string {
+			return strMapper(s, windowsVersionMap)
+		}),
+	}
+)
+
+func staticMapper(fn func(string) string) MapperFactory {
+	return func([]string, map[string][]string) (func(string) string, error) {
+		return fn, nil
+	}
+}
+
+// RegisterMapper makes a mapper available to rule files under the given
+// name. Registering an existing name replaces it.
+func RegisterMapper(name string, factory MapperFactory) {
+	mapperMu.Lock()
+	defer mapperMu.Unlock()
+	mapperRegistry[name] = factory
+}
+
+func lookupMapper(name string) (MapperFactory, bool) {
+	mapperMu.RLock()
+	defer mapperMu.RUnlock()
+	factory, ok := mapperRegistry[name]
+	return factory, ok
+}
+
+// LoadRules reads a rule set. Rule files are YAML documents (JSON is
+// accepted as well, being a subset of YAML). The top level maps a component
+// to its list of rules, which are tried in order:
+//
+//	browser:
+//	  - patterns:
+//	      - '(?i)(kioskapp)\/([\w\.]+)'
+//	    output:
+//	      name: $1
+//	      version: $2
+//	      type: inapp
+//	    mappers:
+//	      - field: version
+//	        func: replace
+//	        args: ["_", "."]
+//	device:
+//	  - patterns: ['(?i)\b(kt-\d{3})\b']
+//	    output: {model: $1, vendor: Kiosk Inc, type: tablet}
+//
+// Components are browser, cpu, device, engine and os. Output fields use the
+// names of the result structs (name, version, major, type, architecture,
+// model, vendor) and their values are templates as in RuleBuilder.Output.
+//
+// Mappers refer to functions by name:
+//
+//	lower              strings.ToLower
+//	upper              strings.ToUpper
+//	trim               strings.TrimSpace
+//	replace            replaces args[0] with args[1], args[2] with args[3], ...
+//	strip_non_numeric  drops non-numeric suffixes such as "1.2beta" -> "1.2"
+//	lookup             maps the value through table; "*" holds the default
+//	windows_version    maps "NT 6.1" style versions to "7"
+//
+// Additional functions can be made available with RegisterMapper.
+//
+// All rules are validated and every problem found is reported in the
+// returned error.
+func LoadRules(r io.Reader) (*Rules, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	var file ruleFile
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+	if err := dec.Decode(&file); err != nil && !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("uaparser: decode rules: %w", err)
+	}
+
+	items := make(map[string][]regexItem, len(file))
+	var errs []error
+	for component, specs := range file {
+		if _, ok := ruleFields[component]; !ok {
+			errs = append(errs, fmt.Errorf("%w: %q", ErrUnknownComponent, component))
+			continue
+		}
+		rules := make([]Rule, 0, len(specs))
+		for i, spec := range specs {
+			rule, err := spec.build()
+			if err != nil {
+				errs = append(errs, fmt.Errorf("%s[%d]: %w", component, i, err))
+				continue
+			}
+			rules = append(rules, rule)
+		}
+		ext, err := NewExtension(map[string][]Rule{component: rules})
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		items[component] = ext[component]
+	}
+	if err := errors.Join(errs...); err != nil {
+		return nil, err
+	}
+	return newRules(items), nil
+}
+
+// LoadRulesFile reads a rule set from a YAML or JSON file.
+func LoadRulesFile(path string) (*Rules, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer func(f *os.File) {
+		_ = f.Close()
+	}(f)
+	return LoadRules(f)
+}
+
+func (spec ruleSpec) build() (Rule, error) {
+	b := NewRule(spec.Patterns...)
+	for field, template := range spec.Output {
+		b.Output(field, template)
+	}
+	for _, m := range spec.Mappers {
+		factory, ok := lookupMapper(m.Func)
+		if !ok {
+			return Rule{}, fmt.Errorf("%w: %q", ErrUnknownMapper, m.Func)
+		}
+		fn, err := factory(m.Args, m.Table)
+		if err != nil {
+			return Rule{}, fmt.Errorf("mapper %q: %w", m.Func, err)
+		}
+		b.Mapper(m.Field, fn)
+	}
+	return b.Build()
+}
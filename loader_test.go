@@ -0,0 +1,75 @@
+package uaparser
+
+import (
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"strings"
+	"testing"
+)
+
+func TestLoadRulesFile(t *testing.T) {
+	rules, err := LoadRulesFile("./data/rules/example.yaml")
+	assert.NoError(t, err)
+	assert.Equal(t, 2, rules.Len(UABrowser))
+	assert.Equal(t, 1, rules.Len(UADevice))
+	assert.Equal(t, 0, rules.Len(UAEngine))
+
+	kiosk := NewUAParser("Mozilla/5.0 (Linux; Android 11; KT-100) KioskApp/2_4_1").WithRules(rules).Result()
+	assert.Equal(t, IBrowser{Name: "Kiosk App", Version: "2.4.1", Major: "2", Type: InApp}, kiosk.Browser)
+	assert.Equal(t, IDevice{Type: Tablet, Model: "KT-100", Vendor: "Kiosk Inc"}, kiosk.Device)
+	assert.Equal(t, IEngine{}, kiosk.Engine)
+
+	partner := NewUAParser("Mozilla/5.0 (Linux; Android 11; KT-300) KioskApp/1.0").WithRules(rules).Device()
+	assert.Equal(t, "Kiosk Partner", partner.Vendor)
+
+	windows := NewUAParser("Mozilla/5.0 (Windows NT 6.1; Win64; x64) Chrome/109.0.0.0").WithRules(rules).Result()
+	assert.Equal(t, IOs{Platform: "Windows", Name: "Windows", Version: "7"}, windows.Os)
+	assert.Equal(t, "Chrome", windows.Browser.Name)
+
+	// Extensions are applied on top of the loaded rules
+	bot := NewUAParser("curl/8.1.2").WithRules(rules).WithExtensions(CLIs).Browser()
+	assert.Equal(t, "curl", bot.Name)
+
+	// nil restores the embedded rules
+	edge := NewUAParser("Mozilla/5.0 (Windows NT 10.0) Chrome/132.0.0.0 Edg/132.0.0.0").WithRules(rules).WithRules(nil).Browser()
+	assert.Equal(t, "Edge", edge.Name)
+}
+
+func TestLoadRules_JSON(t *testing.T) {
+	rules, err := LoadRules(strings.NewReader(`{
+		"cpu": [{"patterns": ["(?i)(riscv64)"], "output": {"architecture": "$1"}, "mappers": [{"field": "architecture", "func": "upper"}]}]
+	}`))
+	assert.NoError(t, err)
+	assert.Equal(t, "RISCV64", NewUAParser("Mozilla/5.0 (X11; Linux riscv64)").WithRules(rules).CPU().Architecture)
+}
+
+func TestLoadRules_Errors(t *testing.T) {
+	_, err := LoadRules(strings.NewReader("gpu:\n  - patterns: ['x']\n    output: {name: x}\n"))
+	assert.True(t, errors.Is(err, ErrUnknownComponent))
+
+	_, err = LoadRules(strings.NewReader("browser:\n  - patterns: ['x']\n    output: {name: x}\n    mappers: [{field: name, func: nope}]\n"))
+	assert.True(t, errors.Is(err, ErrUnknownMapper))
+
+	_, err = LoadRules(strings.NewReader("browser:\n  - patterns: ['x']\n    output: {name: x}\n    mappers: [{field: name, func: replace, args: [a]}]\n"))
+	assert.ErrorContains(t, err, "replace needs pairs")
+
+	_, err = LoadRules(strings.NewReader("browser:\n  - patterns: ['(x']\n    output: {name: $1}\n  - patterns: ['y']\n    output: {model: y}\n"))
+	assert.ErrorContains(t, err, "browser[0]")
+	assert.True(t, errors.Is(err, ErrUnknownField))
+
+	_, err = LoadRules(strings.NewReader("browser:\n  - pattern: ['x']\n"))
+	assert.ErrorContains(t, err, "decode rules")
+}
+
+func TestRegisterMapper(t *testing.T) {
+	RegisterMapper("prefix", func(args []string, _ map[string][]string) (func(string) string, error) {
+		if len(args) != 1 {
+			return nil, errors.New("prefix needs one arg")
+		}
+		return func(s string) string { return args[0] + s }, nil
+	})
+
+	rules, err := LoadRules(strings.NewReader("os:\n  - patterns: ['(?i)kioskos ([\\d\\.]+)']\n    output: {name: KioskOS, version: $1}\n    mappers: [{field: version, func: prefix, args: [v]}]\n"))
+	assert.NoError(t, err)
+	assert.Equal(t, IOs{Name: "KioskOS", Version: "v4.2"}, NewUAParser("Mozilla/5.0 (KioskOS 4.2)").WithRules(rules).Os())
+}
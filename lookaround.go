@@ -0,0 +1,890 @@
+package uaparser
+
+import (
+	"errors"
+	"github.com/dlclark/regexp2"
+	"regexp"
+	"regexp/syntax"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Patterns using lookarounds or backreferences are not supported by the
+// regexp package. Instead of running them on the backtracking regexp2
+// engine they are translated when first used:
+//
+//   - optional lookarounds such as (?=comodo_)? always succeed and are
+//     dropped.
+//   - a single character lookahead followed by a single character atom is
+//     merged into it: (?![lr])\w+ becomes [0-9A-KM-QS-Z_a-km-qs-z]\w*.
+//   - a positive lookahead at the end of the pattern is matched as part of
+//     the pattern and cut from the groups afterwards.
+//   - any other lookaround or a trailing backreference is replaced by an
+//     empty marker group and checked at the marker position after the
+//     match. Without its checks the translated pattern matches a superset
+//     of the original, and its leftmost-first match is the first one a
+//     backtracking engine would try, so a match passing every check is the
+//     match of the original. If a check fails the paths through its marker
+//     are excluded and matching is retried at the same start, then at the
+//     next one. X?(?!C) becomes (?:X()|()) so that both paths are tried.
+//     Unlike a backtracking engine, shorter repetitions before a marker are
+//     not tried, which makes no difference for the built-in rules, see
+//     TestTranslatePattern_Builtin.
+//
+// Patterns that can't be translated, e.g. because a lookaround is repeated
+// by a quantifier, still run on regexp2.
+
+var errUntranslatable = errors.New("uaparser: pattern can't be translated to RE2")
+
+// never matches; it replaces the markers of checks known to fail
+const neverMatch = `[^\x00-\x{10FFFF}]`
+
+type markerKind int
+
+const (
+	markCheck markerKind = iota
+	markCut
+	markBackref
+	markBoundary
+)
+
+type marker struct {
+	kind  markerKind
+	group int // group of the marker in the translated pattern
+	// atEnd markers follow a trailing lookahead and are checked where the
+	// match ends, before the text matched by the lookahead.
+	atEnd bool
+	// markCheck and markBoundary
+	behind, negate bool
+	body, bodyCtx  *compiledPattern
+	// markBackref
+	ref  int // referenced group in the translated pattern
+	fold bool
+}
+
+// compiledPattern is a pattern compiled for the regexp package, or for
+// regexp2 if it can't be translated.
+type compiledPattern struct {
+	re       *regexp.Regexp
+	re2      *regexp2.Regexp
+	timeouts sync.Map // timeout -> *regexp2.Regexp
+
+	// set for translated patterns
+	segments []string // the pattern split at the markers
+	groups   []int    // group in the translated pattern of each original group
+	markers  []marker
+	next     *regexp.Regexp // (?s:.)(?:re), for searches resuming after a rune
+	anchored sync.Map       // disabled markers -> [2]*regexp.Regexp
+}
+
+var errMatchTimeout = errors.New("uaparser: match timeout")
+
+func compilePattern(pattern string) (*compiledPattern, error) {
+	if re, err := regexp.Compile(pattern); err == nil {
+		return &compiledPattern{re: re}, nil
+	}
+	if p, err := translatePattern(pattern); err == nil {
+		return p, nil
+	}
+	re2, err := regexp2.Compile(pattern, 0)
+	if err != nil {
+		return nil, err
+	}
+	return &compiledPattern{re2: re2}, nil
+}
+
+// translated reports whether the pattern runs on the regexp package.
+func (p *compiledPattern) translated() bool {
+	return p.re != nil
+}
+
+// findSubmatch returns the whole match followed by the groups, like
+// regexp.FindStringSubmatch.
+func (p *compiledPattern) findSubmatch(s string) []string {
+	groups, _ := p.findSubmatchTimeout(s, 0)
+	return groups
+}
+
+// findSubmatchTimeout is findSubmatch with a timeout. Patterns running on
+// the regexp package take linear time, but the checks of translated ones
+// may resume the search many times.
+func (p *compiledPattern) findSubmatchTimeout(s string, timeout time.Duration) ([]string, error) {
+	if p.re2 != nil {
+		return submatch2(p.regexp2(timeout), s)
+	}
+	if len(p.markers) == 0 {
+		return p.re.FindStringSubmatch(s), nil
+	}
+
+	loc, err := p.find(s, deadline(timeout))
+	if err != nil || loc == nil {
+		return nil, err
+	}
+	groups := make([]string, len(p.groups)+1)
+	groups[0] = s[loc[0]:loc[1]]
+	for i, g := range p.groups {
+		if loc[2*g] >= 0 {
+			groups[i+1] = s[loc[2*g]:loc[2*g+1]]
+		}
+	}
+	return groups, nil
+}
+
+func submatch2(re *regexp2.Regexp, s string) ([]string, error) {
+	m, err := re.FindStringMatch(s)
+	if err != nil || m == nil {
+		return nil, err
+	}
+	groups := make([]string, len(m.Groups()))
+	for i, group := range m.Groups() {
+		groups[i] = group.String()
+	}
+	return groups, nil
+}
+
+// regexp2 returns the regexp2 pattern with the given MatchTimeout. The
+// timeout is a field of the compiled pattern, so each one is compiled
+// separately.
+func (p *compiledPattern) regexp2(timeout time.Duration) *regexp2.Regexp {
+	if timeout <= 0 {
+		return p.re2
+	}
+	if re, ok := p.timeouts.Load(timeout); ok {
+		return re.(*regexp2.Regexp)
+	}
+	// the pattern compiled before, so it compiles again
+	re := regexp2.MustCompile(p.re2.String(), 0)
+	re.MatchTimeout = timeout
+	p.timeouts.Store(timeout, re)
+	return re
+}
+
+// deadline returns when a match given timeout must be done, or the zero
+// time if there is no timeout.
+func deadline(timeout time.Duration) time.Time {
+	if timeout <= 0 {
+		return time.Time{}
+	}
+	return time.Now().Add(timeout)
+}
+
+// matches reports whether the pattern matches s before the deadline. Only
+// translated patterns are used as lookaround bodies.
+func (p *compiledPattern) matches(s string, deadline time.Time) (bool, error) {
+	if len(p.markers) == 0 {
+		return p.re.MatchString(s), nil
+	}
+	loc, err := p.find(s, deadline)
+	return loc != nil, err
+}
+
+// find returns the submatch indexes of the leftmost match passing the
+// checks of the markers, with the cut and backreference markers applied.
+func (p *compiledPattern) find(s string, deadline time.Time) ([]int, error) {
+	from := 0
+	for from <= len(s) {
+		loc := p.search(s, from)
+		if loc == nil {
+			return nil, nil
+		}
+		start := loc[0]
+		var disabled uint64
+		for loc != nil {
+			failed, err := p.check(s, loc, deadline)
+			if err != nil {
+				return nil, err
+			}
+			if failed == 0 {
+				return p.apply(loc), nil
+			}
+			if !deadline.IsZero() && time.Now().After(deadline) {
+				return nil, errMatchTimeout
+			}
+			disabled |= failed
+			loc = p.matchAt(s, start, disabled)
+		}
+		if start >= len(s) {
+			return nil, nil
+		}
+		_, size := utf8.DecodeRuneInString(s[start:])
+		from = start + size
+	}
+	return nil, nil
+}
+
+// search returns the leftmost match starting at from or later.
+func (p *compiledPattern) search(s string, from int) []int {
+	if from == 0 {
+		return p.re.FindStringSubmatchIndex(s)
+	}
+	// let the rune before from be consumed, so that \b and ^ see it
+	_, size := utf8.DecodeLastRuneInString(s[:from])
+	loc := p.next.FindStringSubmatchIndex(s[from-size:])
+	if loc == nil {
+		return nil
+	}
+	for i := range loc {
+		if loc[i] >= 0 {
+			loc[i] += from - size
+		}
+	}
+	_, size = utf8.DecodeRuneInString(s[loc[0]:])
+	loc[0] += size
+	return loc
+}
+
+// matchAt returns the match starting at start that avoids the disabled
+// markers.
+func (p *compiledPattern) matchAt(s string, start int, disabled uint64) []int {
+	var res [2]*regexp.Regexp
+	if v, ok := p.anchored.Load(disabled); ok {
+		res = v.([2]*regexp.Regexp)
+	} else {
+		var b strings.Builder
+		for i, segment := range p.segments {
+			if i > 0 {
+				if disabled&(1<<(i-1)) != 0 {
+					b.WriteString("(" + neverMatch + ")")
+				} else {
+					b.WriteString("()")
+				}
+			}
+			b.WriteString(segment)
+		}
+		// the pattern compiled before, so its variants compile as well
+		res[0] = regexp.MustCompile(`^(?:` + b.String() + `)`)
+		res[1] = regexp.MustCompile(`^(?s:.)(?:` + b.String() + `)`)
+		p.anchored.Store(disabled, res)
+	}
+
+	if start == 0 {
+		return res[0].FindStringSubmatchIndex(s)
+	}
+	_, size := utf8.DecodeLastRuneInString(s[:start])
+	loc := res[1].FindStringSubmatchIndex(s[start-size:])
+	if loc == nil {
+		return nil
+	}
+	for i := range loc {
+		if loc[i] >= 0 {
+			loc[i] += start - size
+		}
+	}
+	loc[0] = start
+	return loc
+}
+
+// check returns the markers whose checks fail for loc.
+func (p *compiledPattern) check(s string, loc []int, deadline time.Time) (uint64, error) {
+	var failed uint64
+	end := -1
+	for _, m := range p.markers {
+		if m.kind == markCut && loc[2*m.group] >= 0 {
+			end = loc[2*m.group]
+			break
+		}
+	}
+	for k, m := range p.markers {
+		pos := loc[2*m.group]
+		if pos < 0 {
+			continue
+		}
+		if m.atEnd && end >= 0 {
+			pos = end
+		}
+		ok := true
+		var err error
+		switch m.kind {
+		case markBoundary:
+			ok = isWordBoundary(s, pos) != m.negate
+		case markCheck:
+			if m.behind {
+				end := pos
+				body := m.body
+				if pos < len(s) {
+					_, size := utf8.DecodeRuneInString(s[pos:])
+					end, body = pos+size, m.bodyCtx
+				}
+				ok, err = body.matches(s[:end], deadline)
+			} else {
+				start := pos
+				body := m.body
+				if pos > 0 {
+					_, size := utf8.DecodeLastRuneInString(s[:pos])
+					start, body = pos-size, m.bodyCtx
+				}
+				ok, err = body.matches(s[start:], deadline)
+			}
+			ok = ok != m.negate
+		case markBackref:
+			ok = false
+			if ref := loc[2*m.ref]; ref >= 0 {
+				text := s[ref:loc[2*m.ref+1]]
+				if end := pos + len(text); end <= len(s) {
+					ok = s[pos:end] == text || m.fold && strings.EqualFold(s[pos:end], text)
+				}
+			}
+		}
+		if err != nil {
+			return 0, err
+		}
+		if !ok {
+			failed |= 1 << k
+		}
+	}
+	return failed, nil
+}
+
+func isWordBoundary(s string, i int) bool {
+	before := i > 0 && isWordByte(s[i-1])
+	after := i < len(s) && isWordByte(s[i])
+	return before != after
+}
+
+func isWordByte(c byte) bool {
+	return c == '_' || '0' <= c && c <= '9' || 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z'
+}
+
+// apply cuts the text matched by trailing lookaheads from the groups and
+// adds the text matched by trailing backreferences to the match.
+func (p *compiledPattern) apply(loc []int) []int {
+	for _, m := range p.markers {
+		pos := loc[2*m.group]
+		if pos < 0 {
+			continue
+		}
+		switch m.kind {
+		case markCut:
+			for i := 0; i < len(loc); i += 2 {
+				if loc[i] >= 0 && loc[i] <= pos && loc[i+1] > pos {
+					loc[i+1] = pos
+				}
+			}
+		case markBackref:
+			loc[1] = pos + loc[2*m.ref+1] - loc[2*m.ref]
+		}
+	}
+	return loc
+}
+
+// translatePattern translates a pattern using lookarounds or
+// backreferences for the regexp package.
+func translatePattern(pattern string) (*compiledPattern, error) {
+	t := translator{pattern: pattern, flags: leadingFlags(pattern)}
+	if err := t.run(); err != nil {
+		return nil, err
+	}
+	if len(t.markers) > 64 {
+		return nil, errUntranslatable
+	}
+	t.segments = append(t.segments, t.b.String())
+
+	translated := strings.Join(t.segments, "()")
+	re, err := regexp.Compile(translated)
+	if err != nil {
+		return nil, err
+	}
+	return &compiledPattern{
+		re:       re,
+		segments: t.segments,
+		groups:   t.groups,
+		markers:  t.markers,
+		next:     regexp.MustCompile(`(?s:.)(?:` + translated + `)`),
+	}, nil
+}
+
+// leadingFlags returns the flag group pattern starts with, e.g. (?i).
+func leadingFlags(pattern string) string {
+	if !strings.HasPrefix(pattern, "(?") {
+		return ""
+	}
+	for i := 2; i < len(pattern); i++ {
+		switch c := pattern[i]; {
+		case c == ')':
+			return pattern[:i+1]
+		case c != '-' && !unicode.IsLetter(rune(c)):
+			return ""
+		}
+	}
+	return ""
+}
+
+type translator struct {
+	pattern string
+	flags   string
+
+	b        strings.Builder
+	segments []string
+	groups   []int
+	markers  []marker
+	count    int // groups in the translated pattern so far
+	// start in b of the preceding atom if a greedy ? follows it, else -1
+	optional int
+	// set once a trailing lookahead is matched as part of the pattern
+	cut bool
+}
+
+func (t *translator) run() error {
+	p := t.pattern
+	t.optional = -1
+	for i := 0; i < len(p); {
+		atom := t.b.Len()
+		switch {
+		case p[i] == '\\' && i+1 < len(p) && p[i+1] >= '1' && p[i+1] <= '9':
+			end := i + 2
+			for end < len(p) && p[end] >= '0' && p[end] <= '9' {
+				end++
+			}
+			ref, _ := strconv.Atoi(p[i+1 : end])
+			if ref > len(t.groups) || !trailing(p, end) || inRepeatedGroup(p, i) {
+				return errUntranslatable
+			}
+			t.addMarker(marker{kind: markBackref, ref: t.groups[ref-1], fold: strings.Contains(t.flags, "i")})
+			i = end
+			continue
+		case p[i] == '\\' && i+1 < len(p) && (p[i+1] == 'b' || p[i+1] == 'B') && t.cut && trailingZeroWidth(p, i+2):
+			t.addMarker(marker{kind: markBoundary, negate: p[i+1] == 'B', atEnd: true})
+			i += 2
+			continue
+		case p[i] == '\\':
+			end := i + atomEnd(p[i:])
+			if end == i {
+				end = min(i+2, len(p))
+			}
+			t.b.WriteString(p[i:end])
+			i = end
+		case p[i] == '[':
+			end := classEnd(p, i)
+			t.b.WriteString(p[i:end])
+			i = end
+		case isLookaround(p[i:]):
+			next, err := t.lookaround(i)
+			if err != nil {
+				return err
+			}
+			i = next
+			continue
+		case p[i] == '(':
+			if !strings.HasPrefix(p[i:], "(?") || strings.HasPrefix(p[i:], "(?P<") ||
+				strings.HasPrefix(p[i:], "(?<") {
+				t.count++
+				t.groups = append(t.groups, t.count)
+			}
+			t.b.WriteByte('(')
+			t.optional = -1
+			i++
+			continue
+		case atomEnd(p[i:]) == 0:
+			t.b.WriteByte(p[i])
+			t.optional = -1
+			i++
+			continue
+		default:
+			end := i + atomEnd(p[i:])
+			t.b.WriteString(p[i:end])
+			i = end
+		}
+
+		// the atom written to b may be followed by a quantifier
+		q, end := quantifier(p, i)
+		t.b.WriteString(q)
+		i = end
+		t.optional = -1
+		if q == "?" {
+			t.optional = atom
+		}
+	}
+	return nil
+}
+
+func (t *translator) addMarker(m marker) {
+	t.count++
+	m.group = t.count
+	t.markers = append(t.markers, m)
+	t.segments = append(t.segments, t.b.String())
+	t.b.Reset()
+	t.optional = -1
+}
+
+// lookaround translates the lookaround starting at i and returns the index
+// following it.
+func (t *translator) lookaround(i int) (int, error) {
+	p := t.pattern
+	behind := p[i+2] == '<'
+	negate := p[i+2+btoi(behind)] == '!'
+	end := groupEnd(p, i)
+	body := p[i+3+btoi(behind) : end-1]
+	if hasGroupsOrRefs(body) {
+		return 0, errUntranslatable
+	}
+
+	// quantified lookarounds
+	if q, qend := quantifier(p, end); q != "" {
+		if optional(q) {
+			return qend, nil
+		}
+		end = qend
+	}
+
+	if !behind {
+		if merged, next, ok := mergeLookahead(t.flags, body, negate, p[end:]); ok {
+			t.b.WriteString(merged)
+			t.optional = -1
+			return end + next, nil
+		}
+	}
+	if inRepeatedGroup(p, i) {
+		return 0, errUntranslatable
+	}
+	tail := trailingZeroWidth(p, end)
+	if !behind && !negate && !t.cut && tail && stripLookarounds(body) == body {
+		if _, err := regexp.Compile(t.flags + body); err == nil {
+			t.addMarker(marker{kind: markCut})
+			t.b.WriteString("(?:" + body + ")")
+			t.cut = true
+			return end, nil
+		}
+	}
+
+	var src, ctx string
+	if behind {
+		src, ctx = t.flags+`(?:`+body+`)$`, t.flags+`(?:`+body+`)(?s:.)$`
+	} else {
+		src, ctx = t.flags+`^(?:`+body+`)`, t.flags+`^(?s:.)(?:`+body+`)`
+	}
+	m := marker{kind: markCheck, behind: behind, negate: negate, atEnd: t.cut && tail}
+	var err error
+	if m.body, err = compilePattern(src); err != nil || !m.body.translated() {
+		return 0, errUntranslatable
+	}
+	if m.bodyCtx, err = compilePattern(ctx); err != nil || !m.bodyCtx.translated() {
+		return 0, errUntranslatable
+	}
+
+	// X?(?!C) becomes (?:X()|()), so that the check may fail with X and
+	// succeed without it, as it does when backtracking.
+	if t.optional >= 0 && !m.atEnd {
+		prefix := t.b.String()
+		atom := prefix[t.optional : len(prefix)-1]
+		t.b.Reset()
+		t.b.WriteString(prefix[:t.optional] + "(?:" + atom)
+		t.addMarker(m)
+		t.b.WriteString("|")
+		t.addMarker(m)
+		t.b.WriteString(")")
+		return end, nil
+	}
+	t.addMarker(m)
+	return end, nil
+}
+
+func btoi(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+// quantifier returns the quantifier at i, including a lazy suffix, and the
+// index following it.
+func quantifier(p string, i int) (string, int) {
+	if i >= len(p) {
+		return "", i
+	}
+	end := i
+	switch p[i] {
+	case '?', '*', '+':
+		end = i + 1
+	case '{':
+		close := strings.IndexByte(p[i:], '}')
+		if close < 0 {
+			return "", i
+		}
+		end = i + close + 1
+	default:
+		return "", i
+	}
+	if end < len(p) && p[end] == '?' {
+		end++
+	}
+	return p[i:end], end
+}
+
+func optional(q string) bool {
+	return q[0] == '?' || q[0] == '*' || strings.HasPrefix(q, "{0")
+}
+
+func hasGroupsOrRefs(body string) bool {
+	for i := 0; i < len(body); i++ {
+		switch body[i] {
+		case '\\':
+			if i+1 < len(body) && body[i+1] >= '1' && body[i+1] <= '9' {
+				return true
+			}
+			i++
+		case '[':
+			i = classEnd(body, i) - 1
+		case '(':
+			if !strings.HasPrefix(body[i:], "(?") || strings.HasPrefix(body[i:], "(?P<") ||
+				(strings.HasPrefix(body[i:], "(?<") && !isLookaround(body[i:])) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// trailing reports whether nothing can be matched after index i of pattern.
+func trailing(p string, i int) bool {
+	for i < len(p) {
+		switch p[i] {
+		case ')':
+			q, next := quantifier(p, i+1)
+			if q != "" && q[0] != '?' {
+				return false
+			}
+			i = next
+		case '|':
+			i = closingParen(p, i)
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// trailingZeroWidth is like trailing but allows word boundaries and
+// lookarounds, which match no text, to follow.
+func trailingZeroWidth(p string, i int) bool {
+	for i < len(p) {
+		switch {
+		case strings.HasPrefix(p[i:], `\b`) || strings.HasPrefix(p[i:], `\B`):
+			i += 2
+		case isLookaround(p[i:]):
+			_, i = quantifier(p, groupEnd(p, i))
+		case p[i] == ')':
+			q, next := quantifier(p, i+1)
+			if q != "" && q[0] != '?' {
+				return false
+			}
+			i = next
+		case p[i] == '|':
+			i = closingParen(p, i)
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// closingParen returns the index of the parenthesis closing the group
+// containing index i, or len(p) at the top level.
+func closingParen(p string, i int) int {
+	depth := 0
+	for j := i; j < len(p); {
+		switch p[j] {
+		case '\\':
+			j += 2
+			continue
+		case '[':
+			j = classEnd(p, j)
+			continue
+		case '(':
+			depth++
+		case ')':
+			if depth == 0 {
+				return j
+			}
+			depth--
+		}
+		j++
+	}
+	return len(p)
+}
+
+// inRepeatedGroup reports whether index i lies in a group that may match
+// more than once. Markers in such groups would only report the last
+// repetition.
+func inRepeatedGroup(p string, i int) bool {
+	var open []int
+	for j := 0; j < i; {
+		switch p[j] {
+		case '\\':
+			j += 2
+			continue
+		case '[':
+			j = classEnd(p, j)
+			continue
+		case '(':
+			open = append(open, j)
+		case ')':
+			if len(open) > 0 {
+				open = open[:len(open)-1]
+			}
+		}
+		j++
+	}
+	for _, start := range open {
+		if q, _ := quantifier(p, groupEnd(p, start)); q != "" && q[0] != '?' {
+			return true
+		}
+	}
+	return false
+}
+
+// mergeLookahead merges a single character lookahead into the single
+// character atom following it. It returns the merged text and the length of
+// rest it replaces.
+func mergeLookahead(flags, body string, negate bool, rest string) (string, int, bool) {
+	set, ok := charSet(flags + "(?:" + body + ")")
+	if !ok {
+		return "", 0, false
+	}
+	atomLen := atomEnd(rest)
+	if atomLen == 0 {
+		return "", 0, false
+	}
+	atom := rest[:atomLen]
+	atomSet, ok := charSet(flags + atom)
+	if !ok {
+		return "", 0, false
+	}
+	q, end := quantifier(rest, atomLen)
+	if q != "" && optional(q) {
+		return "", 0, false
+	}
+
+	if negate {
+		set = subtractRanges(atomSet, set)
+	} else {
+		set = intersectRanges(atomSet, set)
+	}
+	merged := neverMatch
+	if len(set) > 0 {
+		merged = (&syntax.Regexp{Op: syntax.OpCharClass, Rune: set}).String()
+	}
+	lazy := ""
+	if strings.HasSuffix(q, "?") {
+		q, lazy = q[:len(q)-1], "?"
+	}
+	switch {
+	case q == "":
+	case q == "+":
+		merged += atom + "*" + lazy
+	case strings.HasPrefix(q, "{"):
+		lo, hi, found := strings.Cut(q[1:len(q)-1], ",")
+		n, err := strconv.Atoi(lo)
+		if err != nil {
+			return "", 0, false
+		}
+		switch {
+		case !found:
+			merged += atom + "{" + strconv.Itoa(n-1) + "}" + lazy
+		case hi == "":
+			merged += atom + "{" + strconv.Itoa(n-1) + ",}" + lazy
+		default:
+			m, err := strconv.Atoi(hi)
+			if err != nil {
+				return "", 0, false
+			}
+			merged += atom + "{" + strconv.Itoa(n-1) + "," + strconv.Itoa(m-1) + "}" + lazy
+		}
+	default:
+		return "", 0, false
+	}
+	return merged, end, true
+}
+
+// atomEnd returns the length of the single character atom rest starts
+// with, or 0.
+func atomEnd(rest string) int {
+	if rest == "" {
+		return 0
+	}
+	switch rest[0] {
+	case '\\':
+		if len(rest) < 2 {
+			return 0
+		}
+		if strings.IndexByte("pPx", rest[1]) >= 0 && len(rest) > 2 && rest[2] == '{' {
+			if end := strings.IndexByte(rest, '}'); end >= 0 {
+				return end + 1
+			}
+			return 0
+		}
+		if rest[1] == 'x' || rest[1] >= '0' && rest[1] <= '9' {
+			return 0
+		}
+		return 2
+	case '[':
+		return classEnd(rest, 0)
+	case '(', ')', '|', '^', '$', '?', '*', '+', '{':
+		return 0
+	}
+	_, size := utf8.DecodeRuneInString(rest)
+	return size
+}
+
+// charSet returns the characters matched by a single character pattern.
+func charSet(pattern string) ([]rune, bool) {
+	re, err := syntax.Parse(pattern, syntax.Perl)
+	if err != nil {
+		return nil, false
+	}
+	re = re.Simplify()
+	switch re.Op {
+	case syntax.OpCharClass:
+		return re.Rune, true
+	case syntax.OpAnyCharNotNL:
+		return []rune{0, '\n' - 1, '\n' + 1, unicode.MaxRune}, true
+	case syntax.OpAnyChar:
+		return []rune{0, unicode.MaxRune}, true
+	case syntax.OpLiteral:
+		if len(re.Rune) != 1 {
+			return nil, false
+		}
+		r := re.Rune[0]
+		if re.Flags&syntax.FoldCase == 0 {
+			return []rune{r, r}, true
+		}
+		// build the class from the case folding orbit
+		cls, err := syntax.Parse("(?i)["+regexp.QuoteMeta(string(r))+"]", syntax.Perl)
+		if err != nil || cls.Op != syntax.OpCharClass {
+			return nil, false
+		}
+		return cls.Rune, true
+	}
+	return nil, false
+}
+
+func intersectRanges(a, b []rune) []rune {
+	var out []rune
+	for i := 0; i < len(a); i += 2 {
+		for j := 0; j < len(b); j += 2 {
+			lo, hi := max(a[i], b[j]), min(a[i+1], b[j+1])
+			if lo <= hi {
+				out = append(out, lo, hi)
+			}
+		}
+	}
+	return out
+}
+
+func subtractRanges(a, b []rune) []rune {
+	var complement []rune
+	next := rune(0)
+	for j := 0; j < len(b); j += 2 {
+		if b[j] > next {
+			complement = append(complement, next, b[j]-1)
+		}
+		next = b[j+1] + 1
+	}
+	if next <= unicode.MaxRune {
+		complement = append(complement, next, unicode.MaxRune)
+	}
+	return intersectRanges(a, complement)
+}
@@ -0,0 +1,192 @@
+package uaparser
+
+import (
+	"github.com/dlclark/regexp2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+)
+
+// builtinRules are the rule maps compiled into the package.
+var builtinRules = map[string]map[string][]regexItem{
+	"default":      regexMap,
+	"CLIs":         CLIs,
+	"Crawlers":     Crawlers,
+	"ExtraDevices": ExtraDevices,
+	"Emails":       Emails,
+	"Fetchers":     Fetchers,
+	"InApps":       InApps,
+	"MediaPlayers": MediaPlayers,
+	"Libraries":    Libraries,
+	"Vehicles":     Vehicles,
+	"Bots":         Bots,
+}
+
+// lookaroundPatterns returns the built-in patterns the regexp package can't
+// compile, keyed by "rules/component".
+func lookaroundPatterns() map[string][]string {
+	patterns := make(map[string][]string)
+	for name, rules := range builtinRules {
+		for component, items := range rules {
+			for _, item := range items {
+				for _, pattern := range item.patterns {
+					if _, err := regexp.Compile(pattern); err != nil {
+						key := name + "/" + component
+						patterns[key] = append(patterns[key], pattern)
+					}
+				}
+			}
+		}
+	}
+	return patterns
+}
+
+func TestTranslatePattern(t *testing.T) {
+	tests := []struct {
+		pattern string
+		inputs  []string
+	}{
+		// trailing negative lookahead
+		{`(?i)windows[\/ ]([ntce\d\. ]+\w)(?!.+xbox)`, []string{"Windows NT 10.0; Xbox", "Windows NT 10.0; Win64"}},
+		// lookahead in an alternative followed by another one
+		{`(?i)(?:win(?=3|9|n)|win 9x )([nt\d\.]+)`, []string{"Win 9x 4.90", "WinNT4.0", "Win98"}},
+		// optional lookaround
+		{`(?i)((?=lg)?[vl]k\-?\d{3}) bui`, []string{"LK-430 Build", "VK810 Build"}},
+		// merged single character lookahead
+		{`(?i)\b((?:s[cgp]h|gt|sm)-(?![lr])\w+)`, []string{"SM-R800", "SM-G960F", "SM-L500 SM-G1"}},
+		// trailing lookahead followed by zero width assertions
+		{`(?i)\b(mediapad[\w\. ]*(?= bui|\)))\b(?!.+d\/s)`, []string{"MediaPad T1 8.0 Build/Huawei", "MediaPad M5) a", "MediaPad) Build/s"}},
+		// lookbehind
+		{`(?i)(jsdom|(?<=\()java)\/([\w\.]+)`, []string{"(java/1.8)", "Java/1.8", "jsdom/16"}},
+		// optional atom before a negative lookahead
+		{`(?i)(debian)[-\/ ]?(?!chrom|package)([-\w\.]*)`, []string{"Debian package", "Debian 12", "Debian Chromium"}},
+		// backreference
+		{`(?i)\b; (\w+) build\/hm\1`, []string{"; HM2014811 Build/HM2014811)", "; HM2014811 Build/HM2014812)"}},
+		// nested lookarounds
+		{`(?i)droid.+; (so[-gl]\w+)(?= bui|\).+chrome\/(?![1-6]{0,1}\d\.))`, []string{
+			"Android 9; SO-01K) AppleWebKit Chrome/70.0", "Android 9; SO-01K) AppleWebKit Chrome/60.0",
+		}},
+		// single character lookahead inside a repeated group
+		{`(?i)(aix) ((\d)(?=\.|\)| )[\w\.])*`, []string{"AIX 7.1)", "AIX 5"}},
+	}
+	for _, tt := range tests {
+		p, err := translatePattern(tt.pattern)
+		require.NoError(t, err, tt.pattern)
+		re2 := regexp2.MustCompile(tt.pattern, 0)
+		for _, input := range tt.inputs {
+			assert.Equal(t, regexp2Submatch(re2, input), p.findSubmatch(input), "%s on %q", tt.pattern, input)
+		}
+	}
+}
+
+func TestTranslatePattern_Untranslatable(t *testing.T) {
+	for _, pattern := range []string{
+		`(a(?=b))+`,   // lookaround in a repeated group
+		`(a)\1b`,      // backreference followed by more text
+		`a(?=(b))`,    // group inside a lookaround
+		`(?=(?>ab))a`, // body the regexp package can't compile
+	} {
+		_, err := translatePattern(pattern)
+		assert.Error(t, err, pattern)
+	}
+}
+
+// Failed checks must not drop matches regexp2 finds by backtracking to
+// another alternative or a later start. Shorter repetitions before a marker
+// are not tried, e.g. (\d+)(?!0)(\w+) doesn't match "1230".
+func TestTranslatePattern_Backtracking(t *testing.T) {
+	tests := []struct {
+		pattern string
+		inputs  []string
+	}{
+		{`(\d+)(?!0)(\w+)`, []string{"1231", "100", "10"}},
+		{`(\w+)(?=\d)(\w*)`, []string{"a 1", "abc"}},
+		{`(a|ab)(?!c)(\w*)`, []string{"abc", "ac", "abd"}},
+		{`(\w*)(?<!x)(y+)`, []string{"xyyy", "xy", "ayy"}},
+		{`(\w+)\s(?!foo)(\w+)`, []string{"a b foo bar", "foo bar foo", "x foo"}},
+		{`(\w+)-\1`, []string{"ab-ab", "abc-ab", "ab-abc"}},
+	}
+	for _, tt := range tests {
+		p, err := translatePattern(tt.pattern)
+		require.NoError(t, err, tt.pattern)
+		re2 := regexp2.MustCompile(tt.pattern, 0)
+		for _, input := range tt.inputs {
+			assert.Equal(t, regexp2Submatch(re2, input), p.findSubmatch(input), "%s on %q", tt.pattern, input)
+			ok, _ := re2.MatchString(input)
+			matched, _ := p.matches(input, time.Time{})
+			assert.Equal(t, ok, matched, "%s on %q", tt.pattern, input)
+		}
+	}
+
+	// retrying after failed checks stops at the deadline
+	p, err := translatePattern(`(?i)(\w+)(?!.+xbox)`)
+	require.NoError(t, err)
+	_, err = p.findSubmatchTimeout("Windows NT 10.0; Xbox", time.Nanosecond)
+	assert.ErrorIs(t, err, errMatchTimeout)
+}
+
+// The translated built-in patterns must agree with regexp2 on the test
+// user agents.
+func TestTranslatePattern_Builtin(t *testing.T) {
+	var uas []string
+	for _, path := range []string{"data/ua/browser", "data/ua/cpu", "data/ua/device", "data/ua/engine", "data/ua/os", "data/ua/extension"} {
+		for _, tc := range loadJson(path) {
+			uas = append(uas, tc.Ua)
+		}
+	}
+	for key, patterns := range lookaroundPatterns() {
+		for _, pattern := range patterns {
+			p, err := translatePattern(pattern)
+			if err != nil {
+				continue
+			}
+			re2 := regexp2.MustCompile(pattern, 0)
+			for _, ua := range uas {
+				assert.Equal(t, regexp2Submatch(re2, ua), p.findSubmatch(ua), "%s: %s on %q", key, pattern, ua)
+			}
+		}
+	}
+}
+
+// Built-in patterns that still run on regexp2 are reported here. The
+// default rules must all run on the regexp package, including every pattern
+// tried while parsing the test user agents.
+func TestTranslatePattern_Report(t *testing.T) {
+	for key, patterns := range lookaroundPatterns() {
+		for _, pattern := range patterns {
+			if _, err := translatePattern(pattern); err != nil {
+				t.Logf("%s: not translated: %s", key, pattern)
+				assert.False(t, strings.HasPrefix(key, "default/"), pattern)
+			}
+		}
+	}
+
+	rules := newRules(regexMap)
+	for _, path := range []string{"data/ua/browser", "data/ua/cpu", "data/ua/device", "data/ua/engine", "data/ua/os", "data/ua/extension"} {
+		for _, tc := range loadJson(path) {
+			text := newUAText(tc.Ua)
+			for component := range regexMap {
+				rules.match(text, component, nil)
+			}
+		}
+	}
+	rules.cache.patterns.Range(func(pattern, p any) bool {
+		assert.True(t, p.(*compiledPattern).translated(), "runs on regexp2: %s", pattern)
+		return true
+	})
+}
+
+func regexp2Submatch(re *regexp2.Regexp, s string) []string {
+	m, err := re.FindStringMatch(s)
+	if err != nil || m == nil {
+		return nil
+	}
+	groups := make([]string, len(m.Groups()))
+	for i, group := range m.Groups() {
+		groups[i] = group.String()
+	}
+	return groups
+}
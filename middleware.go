@@ -0,0 +1,115 @@
+package uaparser
+
+import (
+	"context"
+	"net/http"
+	"sync"
+)
+
+// MiddlewareOption configures Middleware.
+type MiddlewareOption func(*middleware)
+
+type middleware struct {
+	parser *Parser
+	opts   []Option
+	eager  bool
+	hints  *HintPolicy
+}
+
+// MiddlewareParser makes the middleware parse with parser instead of one
+// using the embedded rules.
+func MiddlewareParser(parser *Parser) MiddlewareOption {
+	return func(m *middleware) {
+		m.parser = parser
+	}
+}
+
+// MiddlewareCache makes the middleware look up and store its results in
+// cache.
+func MiddlewareCache(cache *ResultCache) MiddlewareOption {
+	return func(m *middleware) {
+		m.opts = append(m.opts, WithCache(cache))
+	}
+}
+
+// MiddlewareExtensions adds rules taking precedence over those of the
+// middleware's parser, including the extensions it was created with.
+func MiddlewareExtensions(extensions Extension) MiddlewareOption {
+	return func(m *middleware) {
+		m.opts = append(m.opts, WithMerge(Prepend, extensions))
+	}
+}
+
+// MiddlewareEager makes the middleware parse every request before calling
+// the next handler. By default a request is parsed the first time
+// FromContext is called for it.
+func MiddlewareEager() MiddlewareOption {
+	return func(m *middleware) {
+		m.eager = true
+	}
+}
+
+// Middleware returns net/http middleware storing the parse result of each
+// request in its context, where handlers get it with FromContext. The
+// User-Agent and client hint headers are parsed at most once per request:
+//
+//	handler = uaparser.Middleware(uaparser.MiddlewareCache(cache))(handler)
+//
+//	func serve(w http.ResponseWriter, r *http.Request) {
+//		result, _ := uaparser.FromContext(r.Context())
+//		...
+//	}
+func Middleware(opts ...MiddlewareOption) func(http.Handler) http.Handler {
+	m := &middleware{parser: defaultParser}
+	for _, opt := range opts {
+		opt(m)
+	}
+	parser := m.parser.with(m.opts...)
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			req := &requestResult{parser: parser, header: r.Header}
+			if m.hints != nil {
+				m.hints.WriteHeaders(w.Header())
+				req.missing, req.retry = m.hints.Missing(r), m.hints.NeedsRetry(r)
+			}
+			if m.eager {
+				req.get()
+			}
+			next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), requestResultKey{}, req)))
+		})
+	}
+}
+
+type requestResultKey struct{}
+
+// requestResult is the parse result of a request, computed once.
+type requestResult struct {
+	parser  *Parser
+	header  http.Header
+	missing []string // hints of the policy the request lacks
+	retry   bool
+
+	once   sync.Once
+	result IResult
+	err    error
+}
+
+func (r *requestResult) get() (IResult, error) {
+	r.once.Do(func() {
+		r.result, r.err = r.parser.ParseHeader(r.header)
+	})
+	return r.result, r.err
+}
+
+// FromContext returns the parse result stored by Middleware. It reports
+// false if ctx doesn't come from a request handled by Middleware. A parse
+// stopped by the parser's Budget returns the components parsed so far.
+func FromContext(ctx context.Context) (IResult, bool) {
+	req, ok := ctx.Value(requestResultKey{}).(*requestResult)
+	if !ok {
+		return IResult{}, false
+	}
+	result, _ := req.get()
+	return result, true
+}
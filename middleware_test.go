@@ -0,0 +1,56 @@
+package uaparser
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestMiddleware(t *testing.T) {
+	serve := func(handler http.Handler, ua string) {
+		req := httptest.NewRequest("GET", "/", nil)
+		req.Header.Set("User-Agent", ua)
+		handler.ServeHTTP(httptest.NewRecorder(), req)
+	}
+	var results []IResult
+	collect := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		for i := 0; i < 2; i++ {
+			result, ok := FromContext(r.Context())
+			assert.True(t, ok)
+			results = append(results, result)
+		}
+	})
+	ignore := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+
+	// lazy: parsed once, only when asked for
+	cache := NewResultCache(10, 0)
+	serve(Middleware(MiddlewareCache(cache))(ignore), cacheChromeUA)
+	assert.Equal(t, CacheStats{}, cache.Stats())
+	serve(Middleware(MiddlewareCache(cache))(collect), cacheChromeUA)
+	assert.Equal(t, CacheStats{Misses: 1, Len: 1}, cache.Stats())
+	assert.Equal(t, []IResult{NewUAParser(cacheChromeUA).Result(), NewUAParser(cacheChromeUA).Result()}, results)
+
+	// eager: parsed before the handler runs
+	serve(Middleware(MiddlewareCache(cache), MiddlewareEager())(ignore), cacheFirefoxUA)
+	assert.Equal(t, CacheStats{Misses: 2, Len: 2}, cache.Stats())
+
+	results = nil
+	serve(Middleware(MiddlewareParser(NewParser()), MiddlewareExtensions(CLIs))(collect), "curl/8.4.0")
+	assert.Equal(t, "curl", results[0].Browser.Name)
+
+	// the extensions of the parser are kept
+	results = nil
+	combined := Middleware(MiddlewareParser(NewParser(WithExtensions(Crawlers))), MiddlewareExtensions(CLIs))(collect)
+	googlebot := "Mozilla/5.0 (compatible; Googlebot/2.1; +http://www.google.com/bot.html)"
+	serve(combined, googlebot)
+	serve(combined, "curl/8.4.0")
+	direct, _ := NewParser(WithExtensions(Crawlers)).Parse(googlebot)
+	assert.Equal(t, "Googlebot", direct.Browser.Name)
+	assert.Equal(t, direct.Browser, results[0].Browser)
+	assert.Equal(t, "curl", results[2].Browser.Name)
+
+	_, ok := FromContext(context.Background())
+	assert.False(t, ok)
+}
@@ -0,0 +1,131 @@
+package uaparser
+
+import (
+	"context"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// HighEntropyHints are the client hints read by the parser that browsers
+// only send once the server has asked for them.
+var HighEntropyHints = []string{
+	CHHeaderFullVerList,
+	CHHeaderArch,
+	CHHeaderBitness,
+	CHHeaderModel,
+	CHHeaderPlatformVer,
+	CHHeaderFormFactors,
+	CHHeaderWow64,
+}
+
+// HintPolicy tells which client hints a server asks browsers for. Hints are
+// named by their request headers, e.g. CHHeaderArch or "Sec-CH-UA-Model".
+type HintPolicy struct {
+	// Hints are requested through Accept-CH and sent by the browser on the
+	// following requests.
+	Hints []string
+	// Critical hints are needed on the current request already. A browser
+	// supporting client hints retries a request that lacks them, so they
+	// are also requested when missing from Hints.
+	Critical []string
+	// Delegate lists third-party origins, e.g. "https://cdn.example.com",
+	// that also receive the hints through Permissions-Policy.
+	Delegate []string
+}
+
+// hints returns the lowercased names of every requested hint.
+func (hp HintPolicy) hints() []string {
+	var hints []string
+	for _, hint := range slices.Concat(hp.Hints, hp.Critical) {
+		hint = strings.ToLower(hint)
+		if !slices.Contains(hints, hint) {
+			hints = append(hints, hint)
+		}
+	}
+	return hints
+}
+
+// WriteHeaders adds the Accept-CH, Critical-CH and Vary headers of the
+// policy to h, and Permissions-Policy if hints are delegated.
+func (hp HintPolicy) WriteHeaders(h http.Header) {
+	hints := hp.hints()
+	if len(hints) == 0 {
+		return
+	}
+	names := make([]string, len(hints))
+	for i, hint := range hints {
+		names[i] = http.CanonicalHeaderKey(hint)
+	}
+	h.Set("Accept-CH", strings.Join(names, ", "))
+	h.Add("Vary", strings.Join(names, ", "))
+
+	if len(hp.Critical) > 0 {
+		critical := make([]string, len(hp.Critical))
+		for i, hint := range hp.Critical {
+			critical[i] = http.CanonicalHeaderKey(hint)
+		}
+		h.Set("Critical-CH", strings.Join(critical, ", "))
+	}
+
+	if len(hp.Delegate) > 0 {
+		origins := []string{"self"}
+		for _, origin := range hp.Delegate {
+			origins = append(origins, strconv.Quote(origin))
+		}
+		allowlist := "(" + strings.Join(origins, " ") + ")"
+		directives := make([]string, len(hints))
+		for i, hint := range hints {
+			// the feature of Sec-CH-UA-Arch is ch-ua-arch
+			directives[i] = strings.TrimPrefix(hint, "sec-") + "=" + allowlist
+		}
+		h.Add("Permissions-Policy", strings.Join(directives, ", "))
+	}
+}
+
+// Missing returns the requested hints r was sent without.
+func (hp HintPolicy) Missing(r *http.Request) []string {
+	var missing []string
+	for _, hint := range hp.hints() {
+		if len(r.Header.Values(hint)) == 0 {
+			missing = append(missing, hint)
+		}
+	}
+	return missing
+}
+
+// NeedsRetry reports whether r lacks a critical hint although it comes from
+// a browser sending client hints, which then retries it with the hints once
+// it has seen the policy. Browsers that don't send Sec-CH-UA never send the
+// hints, so retrying their requests is pointless.
+func (hp HintPolicy) NeedsRetry(r *http.Request) bool {
+	if r.Header.Get(CHHeader) == "" {
+		return false
+	}
+	for _, hint := range hp.Critical {
+		if len(r.Header.Values(hint)) == 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// MiddlewareHints makes the middleware write the headers of policy on every
+// response, and record the hints each request lacks for MissingHints.
+func MiddlewareHints(policy HintPolicy) MiddlewareOption {
+	return func(m *middleware) {
+		m.hints = &policy
+	}
+}
+
+// MissingHints returns the hints requested by the policy of Middleware that
+// the request of ctx was sent without, and whether a retry is warranted as
+// told by HintPolicy.NeedsRetry.
+func MissingHints(ctx context.Context) (missing []string, retry bool) {
+	req, ok := ctx.Value(requestResultKey{}).(*requestResult)
+	if !ok {
+		return nil, false
+	}
+	return req.missing, req.retry
+}
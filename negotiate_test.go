@@ -0,0 +1,61 @@
+package uaparser
+
+import (
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestHintPolicy(t *testing.T) {
+	policy := HintPolicy{
+		Hints:    []string{CHHeaderArch, "Sec-CH-UA-Model"},
+		Critical: []string{CHHeaderPlatformVer},
+		Delegate: []string{"https://cdn.example.com"},
+	}
+	h := http.Header{}
+	policy.WriteHeaders(h)
+	assert.Equal(t, "Sec-Ch-Ua-Arch, Sec-Ch-Ua-Model, Sec-Ch-Ua-Platform-Version", h.Get("Accept-CH"))
+	assert.Equal(t, "Sec-Ch-Ua-Platform-Version", h.Get("Critical-CH"))
+	assert.Equal(t, "Sec-Ch-Ua-Arch, Sec-Ch-Ua-Model, Sec-Ch-Ua-Platform-Version", h.Get("Vary"))
+	assert.Equal(t, `ch-ua-arch=(self "https://cdn.example.com"), ch-ua-model=(self "https://cdn.example.com"), `+
+		`ch-ua-platform-version=(self "https://cdn.example.com")`, h.Get("Permissions-Policy"))
+
+	// Firefox doesn't send client hints at all
+	req := httptest.NewRequest("GET", "/", nil)
+	req.Header.Set("User-Agent", cacheFirefoxUA)
+	assert.Len(t, policy.Missing(req), 3)
+	assert.False(t, policy.NeedsRetry(req))
+
+	req.Header.Set("User-Agent", cacheChromeUA)
+	req.Header.Set("Sec-CH-UA", `"Chromium";v="120"`)
+	req.Header.Set("Sec-CH-UA-Arch", `"x86"`)
+	assert.Equal(t, []string{"sec-ch-ua-model", "sec-ch-ua-platform-version"}, policy.Missing(req))
+	assert.True(t, policy.NeedsRetry(req))
+
+	req.Header.Set("Sec-CH-UA-Platform-Version", `"15.0.0"`)
+	assert.False(t, policy.NeedsRetry(req))
+
+	h = http.Header{}
+	HintPolicy{}.WriteHeaders(h)
+	assert.Empty(t, h)
+}
+
+func TestMiddleware_Hints(t *testing.T) {
+	var missing []string
+	var retry bool
+	handler := Middleware(MiddlewareHints(HintPolicy{Critical: []string{CHHeaderPlatformVer}}))(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			missing, retry = MissingHints(r.Context())
+		}))
+
+	req := httptest.NewRequest("GET", "/", nil)
+	req.Header.Set("User-Agent", cacheChromeUA)
+	req.Header.Set("Sec-CH-UA", `"Chromium";v="120"`)
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+	assert.Equal(t, "Sec-Ch-Ua-Platform-Version", w.Header().Get("Accept-CH"))
+	assert.Equal(t, "Sec-Ch-Ua-Platform-Version", w.Header().Get("Critical-CH"))
+	assert.Equal(t, []string{CHHeaderPlatformVer}, missing)
+	assert.True(t, retry)
+}
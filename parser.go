@@ -0,0 +1,319 @@
+package uaparser
+
+import (
+	"log/slog"
+	"maps"
+	"slices"
+	"sync"
+	"sync/atomic"
+)
+
+// Parser parses user agents with a configuration fixed when it is created.
+// It is never modified afterwards, so a single Parser can be shared by any
+// number of goroutines:
+//
+//	parser := uaparser.NewParser(uaparser.WithExtensions(uaparser.Crawlers))
+//	result, err := parser.Parse(ua)
+//
+// UAParser offers the same parsing through a chaining API for one UA at a
+// time.
+type Parser struct {
+	base    *Rules
+	ruleSet *RuleSet
+	merges  []ruleMerge
+	budget  Budget
+	cache   *ResultCache
+	names   *DeviceNames
+
+	extended atomic.Pointer[extendedRules]
+}
+
+// ruleMerge is a Merge applied to the base rules of a parser.
+type ruleMerge struct {
+	mode MergeMode
+	ext  Extension
+}
+
+// extendedRules remembers the extensions merged onto a base rule set, so a
+// parser only merges again after its RuleSet has been swapped.
+type extendedRules struct {
+	base  *Rules
+	rules *Rules
+}
+
+// Option configures a Parser.
+type Option func(*Parser)
+
+// WithRules makes the parser use rules, e.g. read by LoadRulesFile.
+// Extensions are applied on top of them. A nil rules selects the embedded
+// default set.
+func WithRules(rules *Rules) Option {
+	return func(p *Parser) {
+		if rules == nil {
+			rules = defaultRules
+		}
+		p.base = rules
+		p.ruleSet = nil
+	}
+}
+
+// WithRuleSet makes the parser use whatever rules the RuleSet holds when a
+// parse starts. Each parse works on a single snapshot, so a concurrent Swap
+// or Reload never mixes two rule sets.
+func WithRuleSet(ruleSet *RuleSet) Option {
+	return func(p *Parser) {
+		p.ruleSet = ruleSet
+	}
+}
+
+// WithExtensions adds rules taking precedence over the parser's rules,
+// replacing the extensions and merges set before. The map is copied, and
+// neither it nor its rules are ever modified.
+func WithExtensions(extensions Extension) Option {
+	return func(p *Parser) {
+		p.merges = nil
+		if len(extensions) > 0 {
+			p.merges = []ruleMerge{{mode: Prepend, ext: cloneExtensions(extensions)}}
+		}
+	}
+}
+
+// WithMerge merges ext into the parser's rules as told by mode, after the
+// extensions and merges set before. See Rules.Merge.
+func WithMerge(mode MergeMode, ext Extension) Option {
+	return func(p *Parser) {
+		if len(ext) > 0 {
+			p.merges = append(slices.Clip(p.merges), ruleMerge{mode: mode, ext: cloneExtensions(ext)})
+		}
+	}
+}
+
+// cloneExtensions copies the map of ext. The rule slices are clipped rather
+// than copied, so appending to them never writes into the caller's arrays,
+// and cache keys can still tell the same extensions apart by their arrays.
+func cloneExtensions(ext Extension) Extension {
+	c := maps.Clone(ext)
+	for component, items := range c {
+		c[component] = slices.Clip(items)
+	}
+	return c
+}
+
+// WithBudget sets the time budget of each parse.
+func WithBudget(budget Budget) Option {
+	return func(p *Parser) {
+		p.budget = budget
+	}
+}
+
+// WithCache makes the parser look up and store its results in cache. A nil
+// cache disables caching.
+func WithCache(cache *ResultCache) Option {
+	return func(p *Parser) {
+		p.cache = cache
+	}
+}
+
+// WithDeviceNames makes the parser fill in the marketing names of devices
+// from names, e.g. DefaultDeviceNames or a set read by LoadDeviceNamesFile.
+// A nil names disables the lookup, which is the default.
+func WithDeviceNames(names *DeviceNames) Option {
+	return func(p *Parser) {
+		p.names = names
+	}
+}
+
+// NewParser returns a Parser using the embedded rules and DefaultBudget,
+// modified by opts.
+func NewParser(opts ...Option) *Parser {
+	p := &Parser{base: defaultRules, budget: DefaultBudget}
+	for _, opt := range opts {
+		opt(p)
+	}
+	return p
+}
+
+// with returns a copy of p modified by opts.
+func (p *Parser) with(opts ...Option) *Parser {
+	c := &Parser{
+		base:    p.base,
+		ruleSet: p.ruleSet,
+		merges:  p.merges,
+		budget:  p.budget,
+		cache:   p.cache,
+		names:   p.names,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// Parse parses ua together with optional client hints, of which only the
+// first is used. If the budget runs out it returns the components parsed so
+// far together with a *BudgetError.
+func (p *Parser) Parse(ua string, hints ...ClientHints) (IResult, error) {
+	in := &input{ua: checkUA(ua)}
+	if len(hints) > 0 {
+		in.hints, in.withCH = hints[0], true
+	}
+	return p.parse(in)
+}
+
+func checkUA(ua string) string {
+	if len(ua) >= UAMaxLength {
+		slog.Warn("User-Agent string is too long, it will be ignored")
+		return ""
+	}
+	return ua
+}
+
+// input is the per-request state of a parse. The components parsed for it
+// are remembered, so UAParser can ask for them one at a time.
+type input struct {
+	ua     string
+	hints  ClientHints
+	withCH bool
+	// trace, if set by Explain, receives the explanation of every component
+	// parsed
+	trace map[string]Explanation
+
+	mu    sync.Mutex // guards the fields below
+	text  *uaText
+	rules *Rules // rules data was parsed with
+	data  map[string]map[string]string
+}
+
+// rules returns the rules to parse with, merging the extensions onto the
+// base or RuleSet rules when necessary.
+func (p *Parser) rules() *Rules {
+	base := p.base
+	if p.ruleSet != nil {
+		base = p.ruleSet.Rules()
+	}
+	if len(p.merges) == 0 {
+		return base
+	}
+	if ext := p.extended.Load(); ext != nil && ext.base == base {
+		return ext.rules
+	}
+	rules := base
+	for _, m := range p.merges {
+		rules = rules.Merge(m.mode, m.ext)
+	}
+	p.extended.Store(&extendedRules{base: base, rules: rules})
+	return rules
+}
+
+// parse parses every component of in, going through the cache if there is
+// one.
+func (p *Parser) parse(in *input) (IResult, error) {
+	rules := p.rules()
+	if p.cache == nil {
+		return p.parseAll(in, rules)
+	}
+	key := p.resultKey(in, rules)
+	if result, ok := p.cache.get(key); ok {
+		return result, nil
+	}
+	result, err := p.parseAll(in, rules)
+	if err == nil {
+		p.cache.put(key, result, p.merges)
+	}
+	return result, err
+}
+
+func (p *Parser) parseAll(in *input, rules *Rules) (IResult, error) {
+	budget := p.budget.start()
+	result := IResult{UA: in.ua}
+	var err error
+	if result.Browser, err = p.browser(in, rules, budget); err != nil {
+		return result, err
+	}
+	if result.Engine, err = p.engine(in, rules, budget); err != nil {
+		return result, err
+	}
+	if result.Os, err = p.os(in, rules, budget); err != nil {
+		return result, err
+	}
+	if result.Device, err = p.device(in, rules, budget); err != nil {
+		return result, err
+	}
+	if result.Cpu, err = p.cpu(in, rules, budget); err != nil {
+		return result, err
+	}
+	result.Reduced, result.Unreliable = unreliableFields(result, in.hints, in.withCH)
+	result.Automation = detectAutomation(in.ua, in.hints, in.withCH)
+	result.InApp = DetectInApp(in.ua)
+	return result, nil
+}
+
+// getData parses a component of in, or returns it if it was parsed before
+// with the same rules. Components stopped by the budget are not remembered.
+func (p *Parser) getData(in *input, rules *Rules, budget *parseBudget, itemType string) (map[string]string, error) {
+	if len(in.ua) < UAMinLength && !in.withCH {
+		return make(map[string]string), nil
+	}
+
+	in.mu.Lock()
+	defer in.mu.Unlock()
+	if in.text == nil {
+		in.text = newUAText(in.ua)
+	}
+	if in.rules != rules {
+		in.rules, in.data = rules, make(map[string]map[string]string)
+	}
+	if data, ok := in.data[itemType]; ok {
+		return data, nil
+	}
+
+	uaItem := NewUAItem(itemType, in.ua, rules, in.hints)
+	uaItem.text = in.text
+	uaItem.budget = budget
+	uaItem.parseUA()
+	if uaItem.err != nil {
+		budgetExceeded.Add(1)
+		return uaItem.getData(), uaItem.err
+	}
+	var before map[string]string
+	if in.withCH {
+		if in.trace != nil {
+			before = deepCopyMap(uaItem.data)
+		}
+		uaItem.parseCH()
+	}
+	if in.trace != nil {
+		in.trace[itemType] = explain(rules, itemType, uaItem.match, before, uaItem.data)
+	}
+	in.data[itemType] = uaItem.getData()
+	return uaItem.getData(), nil
+}
+
+func (p *Parser) browser(in *input, rules *Rules, budget *parseBudget) (IBrowser, error) {
+	data, err := p.getData(in, rules, budget, UABrowser)
+	return newBrowser(data), err
+}
+
+func (p *Parser) cpu(in *input, rules *Rules, budget *parseBudget) (ICpu, error) {
+	data, err := p.getData(in, rules, budget, UACpu)
+	return newCpu(data), err
+}
+
+func (p *Parser) device(in *input, rules *Rules, budget *parseBudget) (IDevice, error) {
+	data, err := p.getData(in, rules, budget, UADevice)
+	device := newDevice(data)
+	if p.names != nil {
+		device = p.names.enrich(device, in.ua)
+	}
+	return device, err
+}
+
+func (p *Parser) engine(in *input, rules *Rules, budget *parseBudget) (IEngine, error) {
+	data, err := p.getData(in, rules, budget, UAEngine)
+	return newEngine(data), err
+}
+
+func (p *Parser) os(in *input, rules *Rules, budget *parseBudget) (IOs, error) {
+	data, err := p.getData(in, rules, budget, UAOS)
+	return newOs(data), err
+}
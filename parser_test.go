@@ -0,0 +1,81 @@
+package uaparser
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestParser_Parse(t *testing.T) {
+	parser := NewParser()
+	for _, tc := range loadJson("./data/ua/browser/browser-all.json") {
+		result, err := parser.Parse(tc.Ua)
+		require.NoError(t, err)
+		assert.Equal(t, NewUAParser(tc.Ua).Result(), result, tc.Ua)
+	}
+
+	result, err := parser.Parse(strings.Repeat("a", UAMaxLength))
+	require.NoError(t, err)
+	assert.Equal(t, IResult{}, result)
+}
+
+func TestParser_Hints(t *testing.T) {
+	ua := "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Safari/537.36"
+	hints := NewClientHints(map[string]string{
+		"sec-ch-ua-platform":         `"Windows"`,
+		"sec-ch-ua-platform-version": `"15.0.0"`,
+	})
+
+	result, err := NewParser().Parse(ua, hints)
+	require.NoError(t, err)
+	assert.Equal(t, IOs{Platform: "Windows", Name: "Windows", Version: "11"}, result.Os)
+
+	result, err = NewParser().Parse(ua)
+	require.NoError(t, err)
+	assert.Equal(t, IOs{Platform: "Windows", Name: "Windows", Version: "10"}, result.Os)
+}
+
+func TestParser_Options(t *testing.T) {
+	cache := NewResultCache(10, 0)
+	parser := NewParser(WithExtensions(CLIs), WithCache(cache))
+
+	result, err := parser.Parse("curl/8.4.0")
+	require.NoError(t, err)
+	assert.Equal(t, "curl", result.Browser.Name)
+	_, _ = parser.Parse("curl/8.4.0")
+	assert.Equal(t, uint64(1), cache.Stats().Hits)
+
+	// the parser of a UAParser is copied before it is modified
+	up := NewUAParser("curl/8.4.0")
+	assert.Empty(t, up.Browser().Name)
+	assert.Equal(t, "curl", up.WithExtensions(CLIs).Browser().Name)
+	assert.Empty(t, NewUAParser("curl/8.4.0").Browser().Name)
+	assert.Nil(t, defaultParser.merges)
+}
+
+// Run with -race: a Parser is shared by many goroutines parsing different
+// UAs.
+func TestParser_Concurrent(t *testing.T) {
+	tests := loadJson("./data/ua/extension/cli.json")
+	expected := make([]IResult, len(tests))
+	for i, tc := range tests {
+		expected[i] = NewUAParser(tc.Ua).WithExtensions(CLIs).Result()
+	}
+
+	parser := NewParser(WithExtensions(CLIs), WithCache(NewResultCache(8, 0)))
+	var wg sync.WaitGroup
+	for g := 0; g < 8; g++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i, tc := range tests {
+				result, err := parser.Parse(tc.Ua)
+				assert.NoError(t, err)
+				assert.Equal(t, expected[i], result)
+			}
+		}()
+	}
+	wg.Wait()
+}
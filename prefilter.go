@@ -0,0 +1,575 @@
+package uaparser
+
+import (
+	"regexp/syntax"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// The prefilter avoids running patterns that cannot match. For every pattern
+// sets of literals are derived, each with at least one literal occurring in
+// any string the pattern matches. All literals of a rule set are searched in
+// a single pass with an Aho-Corasick automaton; patterns missing every
+// literal of one of their sets are skipped. Patterns without such literals
+// are always tried, so the first matching rule is the same as without the
+// prefilter.
+
+const (
+	// minLiteralLen is the shortest literal worth filtering on.
+	minLiteralLen = 2
+	// maxLiteralSet bounds the sets built from alternations and classes.
+	maxLiteralSet = 64
+	// maxClassSize is the largest character class expanded into literals.
+	maxClassSize = 10
+)
+
+// requiredLiterals returns sets of literals, lowercased, each having one
+// literal occur in every match of pattern, e.g. windows.+ edge/ requires
+// both windows and " edge/". It returns nil if no such set is known.
+//
+// Lookarounds and backreferences, which the regexp package can't parse, are
+// removed first. They only restrict where a pattern matches, so literals
+// required by the remaining pattern are required by the original as well.
+func requiredLiterals(pattern string) [][]string {
+	re, err := syntax.Parse(stripLookarounds(pattern), syntax.Perl)
+	if err != nil {
+		return nil
+	}
+	info := literalsOf(re.Simplify())
+	var sets [][]string
+	for _, lits := range append([][]string{info.required}, info.also...) {
+		if shortest(lits) >= minLiteralLen && !slices.ContainsFunc(sets, func(set []string) bool {
+			return slices.Equal(set, lits)
+		}) {
+			sets = append(sets, lits)
+		}
+	}
+	return sets
+}
+
+// litInfo describes the strings matched by a regexp node. A nil set means
+// nothing is known.
+type litInfo struct {
+	exact    []string   // every string the node matches
+	prefix   []string   // every match starts with one of these
+	required []string   // every match contains one of these
+	also     [][]string // further sets every match contains one of
+}
+
+var emptyWidth = litInfo{exact: []string{""}, prefix: []string{""}}
+
+func literalsOf(re *syntax.Regexp) litInfo {
+	switch re.Op {
+	case syntax.OpLiteral:
+		lit := []string{strings.ToLower(string(re.Rune))}
+		return litInfo{exact: lit, prefix: lit, required: lit}
+	case syntax.OpCharClass:
+		chars := classChars(re.Rune)
+		return litInfo{exact: chars, prefix: chars, required: chars}
+	case syntax.OpEmptyMatch, syntax.OpBeginLine, syntax.OpEndLine, syntax.OpBeginText,
+		syntax.OpEndText, syntax.OpWordBoundary, syntax.OpNoWordBoundary:
+		return emptyWidth
+	case syntax.OpCapture:
+		return literalsOf(re.Sub[0])
+	case syntax.OpQuest:
+		sub := literalsOf(re.Sub[0])
+		exact := union(sub.exact, []string{""})
+		return litInfo{exact: exact, prefix: exact}
+	case syntax.OpPlus:
+		sub := literalsOf(re.Sub[0])
+		return litInfo{prefix: sub.prefix, required: sub.required, also: sub.also}
+	case syntax.OpRepeat:
+		if re.Min >= 1 {
+			sub := literalsOf(re.Sub[0])
+			return litInfo{prefix: sub.prefix, required: sub.required, also: sub.also}
+		}
+	case syntax.OpAlternate:
+		info := literalsOf(re.Sub[0])
+		for _, sub := range re.Sub[1:] {
+			next := literalsOf(sub)
+			if info.exact = union(info.exact, next.exact); len(info.exact) > maxLiteralSet {
+				info.exact = nil
+			}
+			info.prefix = shrink(union(info.prefix, next.prefix))
+			info.required = shrink(union(info.required, next.required))
+		}
+		info.also = nil
+		if slices.Contains(info.required, "") {
+			info.required = nil
+		}
+		return info
+	case syntax.OpConcat:
+		return concatLiterals(re.Sub)
+	}
+	return litInfo{}
+}
+
+// concatLiterals joins runs of exactly known children into longer literals.
+// When a run is followed by a child with known prefixes the prefixes extend
+// the run, e.g. p(?:af|c[al]) requires one of paf, pca or pcl. The best set
+// becomes required, the others are kept in also.
+func concatLiterals(subs []*syntax.Regexp) litInfo {
+	var info litInfo
+	var best []string
+	consider := func(lits []string) {
+		if lits == nil || slices.Contains(lits, "") {
+			return
+		}
+		if betterLiterals(lits, best) {
+			lits, best = best, lits
+		}
+		if lits != nil {
+			info.also = append(info.also, lits)
+		}
+	}
+
+	run := []string{""}
+	exact := true
+	for _, sub := range subs {
+		next := literalsOf(sub)
+		if joined := cross(run, next.exact); joined != nil {
+			run = joined
+			continue
+		}
+		ext := cross(run, next.prefix)
+		if ext == nil {
+			ext = run
+		}
+		if exact {
+			info.prefix = ext
+			exact = false
+		}
+		consider(ext)
+		consider(next.required)
+		for _, lits := range next.also {
+			consider(lits)
+		}
+		run = []string{""}
+	}
+	consider(run)
+	if exact {
+		info.exact = run
+		info.prefix = run
+	}
+	info.required = best
+	return info
+}
+
+// classChars expands a small character class into its lowercased
+// characters.
+func classChars(ranges []rune) []string {
+	var chars []string
+	for i := 0; i+1 < len(ranges); i += 2 {
+		if ranges[i+1]-ranges[i] >= maxClassSize*2 {
+			return nil
+		}
+		for r := ranges[i]; r <= ranges[i+1]; r++ {
+			if c := strings.ToLower(string(r)); !slices.Contains(chars, c) {
+				chars = append(chars, c)
+			}
+		}
+		if len(chars) > maxClassSize {
+			return nil
+		}
+	}
+	return chars
+}
+
+func union(a, b []string) []string {
+	if a == nil || b == nil {
+		return nil
+	}
+	u := slices.Clone(a)
+	for _, s := range b {
+		if !slices.Contains(u, s) {
+			u = append(u, s)
+		}
+	}
+	if len(u) > 2*maxLiteralSet {
+		return nil
+	}
+	return u
+}
+
+// shrink cuts the strings of a set that grew too large to a common length
+// until it fits. A prefix of a required string is still required, but the
+// result is no longer exact.
+func shrink(lits []string) []string {
+	for n := 4; len(lits) > maxLiteralSet && n >= minLiteralLen; n-- {
+		var t []string
+		for _, lit := range lits {
+			if len(lit) > n {
+				lit = lit[:n]
+			}
+			if !slices.Contains(t, lit) {
+				t = append(t, lit)
+			}
+		}
+		lits = t
+	}
+	if len(lits) > maxLiteralSet {
+		return nil
+	}
+	return lits
+}
+
+func cross(a, b []string) []string {
+	if a == nil || b == nil || len(a)*len(b) > maxLiteralSet {
+		return nil
+	}
+	c := make([]string, 0, len(a)*len(b))
+	for _, x := range a {
+		for _, y := range b {
+			if s := x + y; !slices.Contains(c, s) {
+				c = append(c, s)
+			}
+		}
+	}
+	return c
+}
+
+// betterLiterals prefers the set whose shortest literal is longest, as it
+// occurs less often by chance.
+func betterLiterals(a, b []string) bool {
+	if a == nil {
+		return false
+	}
+	if b == nil {
+		return true
+	}
+	return shortest(a) > shortest(b) || (shortest(a) == shortest(b) && len(a) < len(b))
+}
+
+func shortest(lits []string) int {
+	if lits == nil {
+		return 0
+	}
+	n := len(lits[0])
+	for _, lit := range lits[1:] {
+		n = min(n, len(lit))
+	}
+	return n
+}
+
+// stripLookarounds removes lookaround groups, with any quantifier applied to
+// them, and backreferences from pattern.
+func stripLookarounds(pattern string) string {
+	var b strings.Builder
+	for i := 0; i < len(pattern); {
+		switch {
+		case pattern[i] == '\\' && i+1 < len(pattern):
+			if pattern[i+1] < '1' || pattern[i+1] > '9' {
+				b.WriteString(pattern[i : i+2])
+			}
+			i += 2
+		case pattern[i] == '[':
+			end := classEnd(pattern, i)
+			b.WriteString(pattern[i:end])
+			i = end
+		case isLookaround(pattern[i:]):
+			i = groupEnd(pattern, i)
+			if i < len(pattern) && strings.IndexByte("?*+{", pattern[i]) >= 0 {
+				if pattern[i] == '{' {
+					if end := strings.IndexByte(pattern[i:], '}'); end >= 0 {
+						i += end
+					}
+				}
+				i++
+			}
+		default:
+			b.WriteByte(pattern[i])
+			i++
+		}
+	}
+	return b.String()
+}
+
+func isLookaround(s string) bool {
+	return strings.HasPrefix(s, "(?=") || strings.HasPrefix(s, "(?!") ||
+		strings.HasPrefix(s, "(?<=") || strings.HasPrefix(s, "(?<!")
+}
+
+// classEnd returns the index after the character class starting at i.
+func classEnd(pattern string, i int) int {
+	j := i + 1
+	if j < len(pattern) && pattern[j] == '^' {
+		j++
+	}
+	if j < len(pattern) && pattern[j] == ']' {
+		j++
+	}
+	for j < len(pattern) {
+		switch pattern[j] {
+		case '\\':
+			j += 2
+			continue
+		case ']':
+			return j + 1
+		}
+		j++
+	}
+	return len(pattern)
+}
+
+// groupEnd returns the index after the group opened at i.
+func groupEnd(pattern string, i int) int {
+	depth := 0
+	for j := i; j < len(pattern); {
+		switch pattern[j] {
+		case '\\':
+			j += 2
+			continue
+		case '[':
+			j = classEnd(pattern, j)
+			continue
+		case '(':
+			depth++
+		case ')':
+			depth--
+			if depth == 0 {
+				return j + 1
+			}
+		}
+		j++
+	}
+	return len(pattern)
+}
+
+// acMatcher is an Aho-Corasick automaton compiled into a DFA over the bytes
+// used by its literals.
+type acMatcher struct {
+	class    [256]uint8
+	classes  int
+	delta    []int32 // state*classes+class -> state
+	out      []int32 // literal ending in state, or -1
+	dictLink []int32 // next state on the fail chain with an output, or -1
+	literals int
+}
+
+func newACMatcher(literals []string) *acMatcher {
+	m := &acMatcher{literals: len(literals)}
+	m.classes = 1
+	for _, lit := range literals {
+		for i := 0; i < len(lit); i++ {
+			if m.class[lit[i]] == 0 {
+				m.class[lit[i]] = uint8(m.classes)
+				m.classes++
+			}
+		}
+	}
+
+	// trie
+	newState := func() int32 {
+		m.delta = append(m.delta, make([]int32, m.classes)...)
+		m.out = append(m.out, -1)
+		m.dictLink = append(m.dictLink, -1)
+		return int32(len(m.out) - 1)
+	}
+	newState()
+	for id, lit := range literals {
+		var s int32
+		for i := 0; i < len(lit); i++ {
+			c := int32(m.class[lit[i]])
+			if m.delta[s*int32(m.classes)+c] == 0 {
+				next := newState()
+				m.delta[s*int32(m.classes)+c] = next
+			}
+			s = m.delta[s*int32(m.classes)+c]
+		}
+		m.out[s] = int32(id)
+	}
+
+	// failure links, turning the trie into a DFA breadth first
+	fail := make([]int32, len(m.out))
+	queue := make([]int32, 0, len(m.out))
+	for c := 0; c < m.classes; c++ {
+		if next := m.delta[c]; next != 0 {
+			queue = append(queue, next)
+		}
+	}
+	for len(queue) > 0 {
+		s := queue[0]
+		queue = queue[1:]
+		if f := fail[s]; m.out[f] != -1 {
+			m.dictLink[s] = f
+		} else {
+			m.dictLink[s] = m.dictLink[f]
+		}
+		for c := 0; c < m.classes; c++ {
+			idx := s*int32(m.classes) + int32(c)
+			next := m.delta[idx]
+			target := m.delta[fail[s]*int32(m.classes)+int32(c)]
+			if next == 0 {
+				m.delta[idx] = target
+				continue
+			}
+			fail[next] = target
+			queue = append(queue, next)
+		}
+	}
+	return m
+}
+
+// scan marks the literals occurring in s.
+func (m *acMatcher) scan(s string, hits []bool) {
+	var state int32
+	for i := 0; i < len(s); i++ {
+		state = m.delta[state*int32(m.classes)+int32(m.class[s[i]])]
+		for o := state; o > 0; o = m.dictLink[o] {
+			if id := m.out[o]; id != -1 {
+				hits[id] = true
+			}
+		}
+	}
+}
+
+// prefilter indexes the patterns of a rule set. The literals of all
+// components share one automaton, so a UA is scanned once per parse.
+type prefilter struct {
+	matcher *acMatcher
+	// literal sets per component, rule and pattern; nil means the pattern
+	// is always tried
+	patterns map[string][][][][]int32
+}
+
+func newPrefilter(cache *regexCache, items map[string][]regexItem) *prefilter {
+	ids := make(map[string]int32)
+	var literals []string
+	pf := &prefilter{patterns: make(map[string][][][][]int32, len(items))}
+	for component, items := range items {
+		patterns := make([][][][]int32, len(items))
+		for i, item := range items {
+			patterns[i] = make([][][]int32, len(item.patterns))
+			for j, pattern := range item.patterns {
+				for _, lits := range cache.getLiterals(pattern) {
+					set := make([]int32, len(lits))
+					for k, lit := range lits {
+						id, ok := ids[lit]
+						if !ok {
+							id = int32(len(literals))
+							ids[lit] = id
+							literals = append(literals, lit)
+						}
+						set[k] = id
+					}
+					patterns[i][j] = append(patterns[i][j], set)
+				}
+			}
+		}
+		pf.patterns[component] = patterns
+	}
+	pf.matcher = newACMatcher(literals)
+	return pf
+}
+
+// uaText is a UA prepared for matching. It is shared by the components of
+// a parse, so the UA is lowercased and scanned for literals only once.
+type uaText struct {
+	ua    string
+	lower string // set if ua is ASCII
+	ascii bool
+
+	index *prefilter // index hits belongs to
+	hits  []bool
+}
+
+func newUAText(ua string) *uaText {
+	t := &uaText{ua: ua}
+	for i := 0; i < len(ua); i++ {
+		if ua[i] >= 0x80 {
+			return t
+		}
+	}
+	t.ascii = true
+	t.lower = strings.ToLower(ua)
+	return t
+}
+
+// scan returns which literals of the index occur in the UA.
+func (t *uaText) scan(pf *prefilter) []bool {
+	if t.index != pf {
+		t.hits = make([]bool, pf.matcher.literals)
+		pf.matcher.scan(t.lower, t.hits)
+		t.index = pf
+	}
+	return t.hits
+}
+
+// candidates tells which patterns of a component may match one UA.
+type candidates struct {
+	lower    string
+	enabled  bool
+	at       int // index of the first indexed rule, or -1
+	patterns [][][][]int32
+	hits     []bool
+	cache    *regexCache
+}
+
+// newCandidates looks up the literals of the component in text. Filtering
+// is disabled for non-ASCII input, where case folding could make a pattern
+// match without any of its lowercased literals being present.
+func (r *Rules) newCandidates(text *uaText, component string) *candidates {
+	c := &candidates{cache: r.cache, at: -1}
+	if !text.ascii {
+		return c
+	}
+	c.enabled = true
+	c.lower = text.lower
+
+	if at, ok := r.rootAt[component]; ok && at >= 0 {
+		pf := r.root.prefilter()
+		c.at = at
+		c.patterns = pf.patterns[component]
+		c.hits = text.scan(pf)
+	}
+	return c
+}
+
+// may reports whether pattern j of rule i can match. Rules merged into an
+// indexed rule set look up their literals directly.
+func (c *candidates) may(i, j int, pattern string) bool {
+	if !c.enabled {
+		return true
+	}
+	if c.at < 0 || i < c.at || i >= c.at+len(c.patterns) {
+		for _, lits := range c.cache.getLiterals(pattern) {
+			if !slices.ContainsFunc(lits, func(lit string) bool {
+				return strings.Contains(c.lower, lit)
+			}) {
+				return false
+			}
+		}
+		return true
+	}
+	for _, ids := range c.patterns[i-c.at][j] {
+		if !slices.ContainsFunc(ids, func(id int32) bool {
+			return c.hits[id]
+		}) {
+			return false
+		}
+	}
+	return true
+}
+
+// prefilter returns the index of the rule set, building it on first use.
+func (r *Rules) prefilter() *prefilter {
+	r.indexOnce.Do(func() {
+		r.index = newPrefilter(r.cache, r.items)
+	})
+	return r.index
+}
+
+// literalCache is embedded in regexCache.
+type literalCache struct {
+	lits sync.Map
+}
+
+func (c *literalCache) getLiterals(pattern string) [][]string {
+	if lits, exists := c.lits.Load(pattern); exists {
+		return lits.([][]string)
+	}
+	lits := requiredLiterals(pattern)
+	c.lits.Store(pattern, lits)
+	return lits
+}
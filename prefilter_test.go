@@ -0,0 +1,158 @@
+package uaparser
+
+import (
+	"github.com/stretchr/testify/assert"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestRequiredLiterals(t *testing.T) {
+	tests := []struct {
+		pattern string
+		expect  [][]string
+	}{
+		{`(?i)\bchrome\/([\w\.]+)`, [][]string{{"chrome/"}}},
+		{`p(?:af|c[al])`, [][]string{{"paf", "pca", "pcl"}}},
+		{`(?i)(firefox|fxios)\/([\w\.-]+)`, [][]string{{"firefox/", "fxios/"}}},
+		{`(?i)windows[\/ ]([ntce\d\. ]+\w)(?!.+xbox)`, [][]string{{"windows/", "windows "}}},
+		{`(?i)(?:win(?=3|9|n)|win 9x )([nt\d\.]+)`, [][]string{{"win", "win 9x "}}},
+		{`(?i)windows.+ edge\/([\w\.]+)`, [][]string{{" edge/"}, {"windows"}}},
+		{`(?i)(?: a\d0\d\d)`, [][]string{{" a00", " a10", " a20", " a30", " a40", " a50", " a60", " a70", " a80", " a90"}}},
+		{`(\w+)\/([\w\.]+)`, nil},
+		{`(?i)(a|bc)`, nil},
+		{`x?yz?`, nil},
+	}
+	for _, tt := range tests {
+		got := requiredLiterals(tt.pattern)
+		for _, lits := range append(got, tt.expect...) {
+			slices.Sort(lits)
+		}
+		slices.SortFunc(got, slices.Compare)
+		slices.SortFunc(tt.expect, slices.Compare)
+		assert.Equal(t, tt.expect, got, tt.pattern)
+	}
+}
+
+func TestACMatcher(t *testing.T) {
+	m := newACMatcher([]string{"he", "she", "hers", "his"})
+	hits := make([]bool, 4)
+	m.scan("ushers", hits)
+	assert.Equal(t, []bool{true, true, true, false}, hits)
+
+	hits = make([]bool, 4)
+	m.scan("this", hits)
+	assert.Equal(t, []bool{false, false, false, true}, hits)
+}
+
+// Every pattern matching a fixture UA must contain a literal of each of its
+// sets, otherwise the prefilter would skip a matching rule.
+func TestPrefilter_Literals(t *testing.T) {
+	var uas []string
+	for _, path := range []string{"data/ua/browser", "data/ua/cpu", "data/ua/device", "data/ua/engine", "data/ua/os"} {
+		for _, tc := range loadJson(path) {
+			uas = append(uas, tc.Ua)
+		}
+	}
+	cache := &regexCache{}
+	for component, items := range regexMap {
+		for _, item := range items {
+			for _, pattern := range item.patterns {
+				sets := requiredLiterals(pattern)
+				if sets == nil {
+					continue
+				}
+				for _, ua := range uas {
+					if _, groups, _ := applyPattern(cache, ua, pattern, item.output, 0); groups == nil {
+						continue
+					}
+					lower := strings.ToLower(ua)
+					for _, lits := range sets {
+						assert.True(t, slices.ContainsFunc(lits, func(lit string) bool {
+							return strings.Contains(lower, lit)
+						}), "%s: %s matches %q without any of %q", component, pattern, ua, lits)
+					}
+				}
+			}
+		}
+	}
+}
+
+func TestPrefilter_SameResult(t *testing.T) {
+	rules := defaultRules.extend(CLIs)
+	uas := []string{
+		"Mozilla/5.0 (Linux; Android 12; BRT-AN09) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/109.0.0.0 Safari/537.36 EdgA/109.0.1518.53",
+		"Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Safari/537.36",
+		"MOZILLA/5.0 (WINDOWS NT 10.0; WIN64; X64; RV:109.0) GECKO/20100101 FIREFOX/115.0",
+		"Mozilla/5.0 (Linux; Android 13; Pixel 7 ünïcode) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/116.0 Mobile Safari/537.36",
+		"curl/8.4.0",
+		"Googlebot/2.1 (+http://www.google.com/bot.html)",
+	}
+	for _, ua := range uas {
+		for _, component := range []string{UABrowser, UACpu, UADevice, UAEngine, UAOS} {
+			got, _, _ := rules.match(newUAText(ua), component, nil)
+			assert.Equal(t, matchAll(rules, ua, component), got, "%s %s", component, ua)
+		}
+	}
+}
+
+// matchAll runs every pattern in order, without the prefilter.
+func matchAll(r *Rules, ua string, component string) map[string]string {
+	for _, item := range r.items[component] {
+		for _, pattern := range item.patterns {
+			if result, groups, _ := applyPattern(r.cache, ua, pattern, item.output, 0); groups != nil {
+				for _, mp := range item.mapperItems {
+					if mp.field != "" {
+						result[mp.field] = mp.fn(result[mp.field])
+					}
+				}
+				return result
+			}
+		}
+	}
+	return make(map[string]string)
+}
+
+// Parsing the components of a common UA, with and without the prefilter.
+func BenchmarkPrefilter(b *testing.B) {
+	ua := "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/132.0.0.0 Safari/537.36 Edg/132.0.0.0"
+	components := []string{UABrowser, UACpu, UADevice, UAEngine, UAOS}
+	b.Run("Prefilter", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			text := newUAText(ua)
+			for _, component := range components {
+				defaultRules.match(text, component, nil)
+			}
+		}
+	})
+	b.Run("All", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			for _, component := range components {
+				matchAll(defaultRules, ua, component)
+			}
+		}
+	})
+	b.Run("Result", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			_ = NewUAParser(ua).Result()
+		}
+	})
+}
+
+// Full results of common UAs, the figure quoted in the README.
+func BenchmarkResult(b *testing.B) {
+	for _, tt := range []struct{ name, ua string }{
+		{"Chrome", "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/132.0.0.0 Safari/537.36"},
+		{"Edge", "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/132.0.0.0 Safari/537.36 Edg/132.0.0.0"},
+		{"Firefox", "Mozilla/5.0 (Windows NT 10.0; Win64; x64; rv:133.0) Gecko/20100101 Firefox/133.0"},
+		{"Safari", "Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/17.5 Safari/605.1.15"},
+		{"Android", "Mozilla/5.0 (Linux; Android 10; K) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/132.0.0.0 Mobile Safari/537.36"},
+		{"iPhone", "Mozilla/5.0 (iPhone; CPU iPhone OS 17_5 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/17.5 Mobile/15E148 Safari/604.1"},
+	} {
+		b.Run(tt.name, func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				_ = NewUAParser(tt.ua).Result()
+			}
+		})
+	}
+}
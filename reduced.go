@@ -0,0 +1,77 @@
+package uaparser
+
+import (
+	"regexp"
+	"strings"
+)
+
+// Fields of IResult reported by IResult.Unreliable.
+const (
+	FieldBrowserVersion  = "browser.version"
+	FieldEngineVersion   = "engine.version"
+	FieldOSVersion       = "os.version"
+	FieldDeviceModel     = "device.model"
+	FieldCPUArchitecture = "cpu.architecture"
+)
+
+// frozenUAReg matches the UAs of Chromium-based browsers with User-Agent
+// reduction, which freezes the platform part and the minor versions. Each
+// platform has its own group, see frozenFields.
+var frozenUAReg = regexp.MustCompile(`^Mozilla/5\.0 \((?:(Windows NT 10\.0; (?:Win64; x64|WOW64))|(X11; CrOS x86_64 14541\.0\.0)|(X11; Linux x86_64)|(Macintosh; Intel Mac OS X 10_15_7)|(Linux; Android 10; K))\) AppleWebKit/537\.36 \(KHTML, like Gecko\) Chrome/\d+\.0\.0\.0 (?:Mobile )?Safari/537\.36`)
+
+// frozenFields are the fields frozen by each platform of frozenUAReg, after
+// the engine version frozen by all of them.
+var frozenFields = [][]string{
+	{FieldOSVersion, FieldCPUArchitecture}, // Windows 11 and ARM devices say Windows 10 on x64
+	{FieldOSVersion, FieldCPUArchitecture}, // Chrome OS
+	{FieldCPUArchitecture},                 // Linux
+	{FieldOSVersion, FieldCPUArchitecture}, // macOS, Apple silicon included
+	{FieldOSVersion, FieldDeviceModel},     // Android, whose model is "K"
+}
+
+// IsFrozenUA reports whether ua is a reduced User-Agent string, whose OS
+// version, minor browser versions and device model are frozen.
+func IsFrozenUA(ua string) bool {
+	return frozenUAReg.MatchString(ua)
+}
+
+// unreliableFields returns whether the UA of result is reduced, and which
+// fields parsed from it are frozen values not replaced by client hints.
+func unreliableFields(result IResult, hints ClientHints, withCH bool) (bool, []string) {
+	m := frozenUAReg.FindStringSubmatchIndex(result.UA)
+	if m == nil {
+		return false, nil
+	}
+	var fields []string
+	// browsers such as Edge keep their own full version next to Chrome's
+	if strings.HasSuffix(result.Browser.Version, ".0.0.0") {
+		fields = append(fields, FieldBrowserVersion)
+	}
+	fields = append(fields, FieldEngineVersion)
+	for i, platform := range frozenFields {
+		if m[2*(i+1)] >= 0 {
+			fields = append(fields, platform...)
+		}
+	}
+	if !withCH {
+		return true, fields
+	}
+
+	provided := map[string]bool{
+		FieldBrowserVersion:  len(hints.FullVersionList) > 0 || hints.FullVersion != "",
+		FieldEngineVersion:   len(hints.FullVersionList) > 0,
+		FieldOSVersion:       hints.PlatformVersion != "",
+		FieldDeviceModel:     hints.Model != "",
+		FieldCPUArchitecture: hints.Architecture != "",
+	}
+	unreliable := fields[:0]
+	for _, field := range fields {
+		if !provided[field] {
+			unreliable = append(unreliable, field)
+		}
+	}
+	if len(unreliable) == 0 {
+		return true, nil
+	}
+	return true, unreliable
+}
@@ -0,0 +1,54 @@
+package uaparser
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestIsFrozenUA(t *testing.T) {
+	tests := []struct {
+		ua       string
+		expected bool
+	}{
+		{"Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/132.0.0.0 Safari/537.36", true},
+		{"Mozilla/5.0 (Linux; Android 10; K) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/132.0.0.0 Mobile Safari/537.36", true},
+		{"Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/132.0.0.0 Safari/537.36", true},
+		{"Mozilla/5.0 (X11; CrOS x86_64 14541.0.0) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/132.0.0.0 Safari/537.36", true},
+		{"Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/109.0.5414.120 Safari/537.36", false},
+		{"Mozilla/5.0 (Linux; Android 14; SM-S918B) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/132.0.0.0 Mobile Safari/537.36", false},
+		{cacheFirefoxUA, false},
+	}
+	for _, tc := range tests {
+		assert.Equal(t, tc.expected, IsFrozenUA(tc.ua), tc.ua)
+	}
+}
+
+func TestResult_Unreliable(t *testing.T) {
+	android := "Mozilla/5.0 (Linux; Android 10; K) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/132.0.0.0 Mobile Safari/537.36"
+	result := NewUAParser(android).Result()
+	assert.True(t, result.Reduced)
+	assert.Equal(t, []string{FieldBrowserVersion, FieldEngineVersion, FieldOSVersion, FieldDeviceModel}, result.Unreliable)
+	assert.Equal(t, "K", result.Device.Model)
+
+	// client hints replace the frozen values
+	result = NewUAParser(android).WithHeaders(map[string]string{
+		CHHeaderFullVerList: `"Chromium";v="132.0.6834.163", "Google Chrome";v="132.0.6834.163"`,
+		CHHeaderModel:       `"Pixel 8"`,
+		CHHeaderPlatform:    `"Android"`,
+		CHHeaderPlatformVer: `"15.0.0"`,
+	}).Result()
+	assert.True(t, result.Reduced)
+	assert.Empty(t, result.Unreliable)
+	assert.Equal(t, "Pixel 8", result.Device.Model)
+	assert.Equal(t, "15.0.0", result.Os.Version)
+	assert.Equal(t, "132.0.6834.163", result.Browser.Version)
+
+	result = NewUAParser("Mozilla/5.0 (X11; Linux x86_64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/132.0.0.0 Safari/537.36").
+		WithHeaders(map[string]string{CHHeaderArch: `"arm"`, CHHeaderBitness: `"64"`}).Result()
+	assert.Equal(t, []string{FieldBrowserVersion, FieldEngineVersion}, result.Unreliable)
+	assert.Equal(t, "arm64", result.Cpu.Architecture)
+
+	result = NewUAParser(cacheFirefoxUA).Result()
+	assert.False(t, result.Reduced)
+	assert.Nil(t, result.Unreliable)
+}
@@ -1,13 +1,13 @@
 package uaparser
 
 import (
+	"errors"
 	"fmt"
-	"github.com/dlclark/regexp2"
-	"log/slog"
 	"regexp"
 	"strconv"
 	"strings"
 	"sync"
+	"time"
 )
 
 const (
@@ -22,6 +22,7 @@ var (
 	NonNumericOrDotReg    = regexp.MustCompile(`[^\d.]`)
 	NonNumericSequenceReg = regexp.MustCompile(`[^\d.]+.`)
 	quoteReplaceReg       = regexp.MustCompile(`\\?"`)
+	wow64Reg              = regexp.MustCompile(`(?i)\bwow64\b`)
 )
 
 type mapperItem struct {
@@ -1858,6 +1859,10 @@ func strMapper(str string, m map[string][]string) string {
 		}
 	}
 	if value, ok := m["*"]; ok {
+		// an empty fallback maps unknown values to ""
+		if len(value) == 0 {
+			return ""
+		}
 		return value[0]
 	}
 	return str
@@ -1867,30 +1872,23 @@ func majorize(version string) string {
 	return strings.Split(NonNumericOrDotReg.ReplaceAllString(version, ""), ".")[0]
 }
 
-var (
-	reCache  sync.Map
-	re2Cache sync.Map
-)
+// regexCache holds the compiled patterns of a rule set. It lives as long as
+// the rule set it belongs to.
+type regexCache struct {
+	patterns sync.Map
+	literalCache
+}
 
-func getCachedRegexp(pattern string) (*regexp.Regexp, error) {
-	if re, exists := reCache.Load(pattern); exists {
-		return re.(*regexp.Regexp), nil
+func (c *regexCache) getPattern(pattern string) (*compiledPattern, error) {
+	if p, exists := c.patterns.Load(pattern); exists {
+		return p.(*compiledPattern), nil
 	}
-	re, err := regexp.Compile(pattern)
+	p, err := compilePattern(pattern)
 	if err != nil {
 		return nil, err
 	}
-	reCache.Store(pattern, re)
-	return re, nil
-}
-
-func getCachedRegexp2(pattern string) (*regexp2.Regexp, error) {
-	if re, exists := re2Cache.Load(pattern); exists {
-		return re.(*regexp2.Regexp), nil
-	}
-	re := regexp2.MustCompile(pattern, 0)
-	re2Cache.Store(pattern, re)
-	return re, nil
+	c.patterns.Store(pattern, p)
+	return p, nil
 }
 
 func deepCopyMap(original map[string]string) map[string]string {
@@ -1911,7 +1909,10 @@ func extractIndex(value string) (int, error) {
 	return 0, nil
 }
 
-func applyPattern(ua string, pattern string, output map[string]string) (map[string]string, bool) {
+// applyPattern matches ua against pattern and fills output with the groups.
+// It returns nil groups if the pattern doesn't match, and an error if the
+// match timed out.
+func applyPattern(cache *regexCache, ua string, pattern string, output map[string]string, timeout time.Duration) (map[string]string, []string, error) {
 	processMatches := func(matches []string, output map[string]string) map[string]string {
 		result := deepCopyMap(output)
 		for key, value := range output {
@@ -1927,132 +1928,207 @@ func applyPattern(ua string, pattern string, output map[string]string) (map[stri
 		return result
 	}
 
-	// Attempt to get the regex from cache
-	re, err := getCachedRegexp(pattern)
-	if err == nil {
-		matches := re.FindStringSubmatch(ua)
-		if len(matches) > 0 {
-			return processMatches(matches, output), true
-		}
+	re, err := cache.getPattern(pattern)
+	if err != nil {
+		return nil, nil, nil
 	}
-
-	// Fallback to regex2 cache and matching
-	re2, err := getCachedRegexp2(pattern)
-	if err == nil {
-		matches, err := re2.FindStringMatch(ua)
-		if err == nil && matches != nil {
-			groups := make([]string, len(matches.Groups()))
-			for i, group := range matches.Groups() {
-				groups[i] = group.String()
-			}
-			return processMatches(groups, output), true
-		}
+	matches, err := re.findSubmatchTimeout(ua, timeout)
+	if len(matches) == 0 {
+		return nil, nil, err
 	}
-
-	return nil, false
+	return processMatches(matches, output), matches, nil
 }
 
-func parseUA(ua string, regexItems []regexItem) map[string]string {
-	for _, regItem := range regexItems {
-		for _, pattern := range regItem.patterns {
-			result, matched := applyPattern(ua, pattern, regItem.output)
-			if matched {
-				// Apply mapping functions
-				for _, mp := range regItem.mapperItems {
-					if mp.field != "" {
-						result[mp.field] = mp.fn(result[mp.field])
-					}
-				}
-				return result
-			}
-		}
-	}
-	return make(map[string]string)
+// ruleMatch records which rule and pattern produced a parse result.
+type ruleMatch struct {
+	rule    int
+	pattern int
+	groups  []string
 }
 
-// ClientHints User Agent Client Hints data
+// ClientHints User Agent Client Hints data, read from the Sec-CH-UA-*
+// headers by NewClientHints or from navigator.userAgentData by
+// ClientHintsFromUAData.
 type ClientHints struct {
-	brands       []IBrand
-	fullVerList  []IBrand
-	mobile       bool
-	model        string
-	platform     string
-	platformVer  string
-	formFactors  []string
-	architecture string
-	bitness      string
+	Brands          []IBrand `json:"brands,omitempty"`
+	FullVersionList []IBrand `json:"fullVersionList,omitempty"`
+	Mobile          bool     `json:"mobile,omitempty"`
+	Model           string   `json:"model,omitempty"`
+	Platform        string   `json:"platform,omitempty"`
+	PlatformVersion string   `json:"platformVersion,omitempty"`
+	FormFactors     []string `json:"formFactors,omitempty"`
+	Architecture    string   `json:"architecture,omitempty"`
+	Bitness         string   `json:"bitness,omitempty"`
+	Wow64           bool     `json:"wow64,omitempty"`
+	// FullVersion is the full version of the browser, sent by browsers
+	// predating FullVersionList.
+	FullVersion string `json:"uaFullVersion,omitempty"`
 }
 
+// NewClientHints reads the client hint headers, keyed by their lowercased
+// names. Headers that aren't valid structured fields are read leniently, see
+// ParseClientHints.
 func NewClientHints(headers map[string]string) ClientHints {
+	ch, _ := ParseClientHints(headers)
+	return ch
+}
 
-	stripQuotes := func(str string) string {
-		return strings.ReplaceAll(str, "\"", "")
+// ParseClientHints reads the client hint headers, keyed by their lowercased
+// names, as the Structured Field Values (RFC 8941) they are defined as. A
+// header that doesn't parse is read leniently instead, e.g. an unquoted
+// model, and reported by the returned error, which joins a *HeaderError per
+// such header.
+func ParseClientHints(headers map[string]string) (ClientHints, error) {
+	var errs []error
+	report := func(header string, err error) {
+		var he *HeaderError
+		if errors.As(err, &he) {
+			he.Header = header
+		}
+		errs = append(errs, err)
 	}
 
-	itemListToArray := func(header string) []IBrand {
-		if header == "" {
+	brands := func(header string) []IBrand {
+		value, ok := headers[header]
+		if !ok || value == "" {
 			return nil
 		}
-		var arr []IBrand
-
-		tokens := strings.Split(quoteReplaceReg.ReplaceAllString(header, ""), ",")
-		for _, token := range tokens {
-			if strings.Contains(token, ";") {
-				parts := strings.Split(strings.Trim(token, " "), ";v=")
-				arr = append(arr, IBrand{
-					Name:    parts[0],
-					Version: parts[1],
-				})
-			} else {
-				arr = append(arr, IBrand{
-					Name: strings.TrimSpace(token),
-				})
+		list, err := parseSFList(value)
+		if err == nil {
+			if arr, ok := brandList(list); ok {
+				return arr
 			}
+			err = &HeaderError{Value: value, Reason: "brands are not separate strings"}
 		}
-		return arr
+		report(header, err)
+		return lenientBrandList(value)
 	}
 
-	itemListToArray2 := func(header string) []string {
-		if header == "" {
+	stringList := func(header string) []string {
+		value, ok := headers[header]
+		if !ok || value == "" {
 			return nil
 		}
+		list, err := parseSFList(value)
+		if err == nil {
+			var arr []string
+			for _, member := range list {
+				if s, ok := member.value.(string); ok && member.inner == nil {
+					arr = append(arr, s)
+				}
+			}
+			return arr
+		}
+		report(header, err)
 		var arr []string
-
-		tokens := strings.Split(quoteReplaceReg.ReplaceAllString(header, ""), ",")
-		for _, token := range tokens {
-			arr = append(arr, strings.TrimSpace(token))
+		for _, token := range splitSFList(value) {
+			arr = append(arr, lenientString(token))
 		}
 		return arr
 	}
 
-	mobile, _ := regexp.MatchString(`\?1`, headers[CHHeaderMobile])
+	str := func(header string) string {
+		value, ok := headers[header]
+		if !ok || value == "" {
+			return ""
+		}
+		item, err := parseSFItem(value)
+		if err == nil {
+			if s, ok := item.value.(string); ok {
+				return s
+			}
+			err = &HeaderError{Value: value, Reason: "not a string"}
+		}
+		report(header, err)
+		return lenientString(value)
+	}
+
+	boolean := func(header string) bool {
+		value, ok := headers[header]
+		if !ok || value == "" {
+			return false
+		}
+		item, err := parseSFItem(value)
+		if b, ok := item.value.(bool); err == nil && ok {
+			return b
+		}
+		if err == nil {
+			err = &HeaderError{Value: value, Reason: "not a boolean"}
+		}
+		report(header, err)
+		return strings.Contains(value, "?1")
+	}
 
 	return ClientHints{
-		brands:       itemListToArray(headers[CHHeader]),
-		fullVerList:  itemListToArray(headers[CHHeaderFullVerList]),
-		mobile:       mobile,
-		model:        stripQuotes(headers[CHHeaderModel]),
-		platform:     stripQuotes(headers[CHHeaderPlatform]),
-		platformVer:  stripQuotes(headers[CHHeaderPlatformVer]),
-		formFactors:  itemListToArray2(headers[CHHeaderFormFactors]),
-		architecture: stripQuotes(headers[CHHeaderArch]),
-		bitness:      stripQuotes(headers[CHHeaderBitness]),
+		Brands:          brands(CHHeader),
+		FullVersionList: brands(CHHeaderFullVerList),
+		Mobile:          boolean(CHHeaderMobile),
+		Model:           str(CHHeaderModel),
+		Platform:        str(CHHeaderPlatform),
+		PlatformVersion: str(CHHeaderPlatformVer),
+		FormFactors:     stringList(CHHeaderFormFactors),
+		Architecture:    str(CHHeaderArch),
+		Bitness:         str(CHHeaderBitness),
+		Wow64:           boolean(CHHeaderWow64),
+		FullVersion:     str(CHHeaderFullVersion),
+	}, errors.Join(errs...)
+}
+
+// brandList reads the brands of a brand list such as Sec-CH-UA, whose
+// members are strings with the version in the v parameter. It fails on
+// lists quoted as a whole, as in "Chromium;v=120, Not_A Brand;v=8".
+func brandList(list []sfMember) ([]IBrand, bool) {
+	arr := make([]IBrand, 0, len(list))
+	for _, member := range list {
+		name, ok := member.value.(string)
+		if !ok || member.inner != nil || strings.Contains(name, ";v=") {
+			return nil, false
+		}
+		version, _ := member.param("v")
+		v, _ := version.(string)
+		arr = append(arr, IBrand{Name: name, Version: v})
 	}
+	return arr, true
+}
+
+// lenientBrandList reads a brand list that isn't a valid structured field
+// as well as it can.
+func lenientBrandList(value string) []IBrand {
+	var arr []IBrand
+	for _, token := range splitSFList(value) {
+		parts := splitSF(token, ';')
+		if name := lenientString(parts[0]); len(parts) == 1 && strings.Contains(name, ";v=") {
+			arr = append(arr, lenientBrandList(name)...)
+			continue
+		}
+		brand := IBrand{Name: lenientString(parts[0])}
+		for _, param := range parts[1:] {
+			if k, v, ok := strings.Cut(param, "="); ok && strings.TrimSpace(k) == "v" {
+				brand.Version = lenientString(v)
+			}
+		}
+		arr = append(arr, brand)
+	}
+	return arr
 }
 
 type UAItem struct {
 	itemType string // browser, engine, os, device, cpu.
 	ua       string
 	uaCH     ClientHints
-	rgxMap   map[string][]regexItem
+	rules    *Rules
+	text     *uaText // ua 预处理结果，可在各组件间共享
+	budget   *parseBudget
 	data     map[string]string // ua 解析结果
+	match    *ruleMatch        // 命中的规则
+	err      error             // 超出时间预算
 }
 
-func NewUAItem(itemType string, ua string, rgxMap map[string][]regexItem, uaCH ClientHints) *UAItem {
+func NewUAItem(itemType string, ua string, rules *Rules, uaCH ClientHints) *UAItem {
 	return &UAItem{
 		itemType: itemType,
 		ua:       ua,
-		rgxMap:   rgxMap,
+		rules:    rules,
 		uaCH:     uaCH,
 		data:     make(map[string]string),
 	}
@@ -2060,13 +2136,13 @@ func NewUAItem(itemType string, ua string, rgxMap map[string][]regexItem, uaCH C
 
 func (item *UAItem) parseCH() *UAItem {
 	uaCh := item.uaCH
-	rgxMap := item.rgxMap
+	rules := item.rules
 
 	switch item.itemType {
 	case UABrowser, UAEngine:
-		brands := uaCh.fullVerList
+		brands := uaCh.FullVersionList
 		if brands == nil {
-			brands = uaCh.brands
+			brands = uaCh.Brands
 		}
 		var prevName string
 		for _, brand := range brands {
@@ -2081,6 +2157,10 @@ func (item *UAItem) parseCH() *UAItem {
 
 			if item.itemType == UABrowser && !notBrandReg.MatchString(brandName) &&
 				(prevName == "" || (strings.Contains(strings.ToLower(prevName), "chrom") && brandName != Chromium)) {
+				// Sec-CH-UA holds major versions only
+				if uaCh.FullVersionList == nil && majorize(uaCh.FullVersion) == brandVersion {
+					brandVersion = uaCh.FullVersion
+				}
 				item.data[Name] = brandName
 				item.data[Version] = brandVersion
 				item.data[Major] = majorize(brandVersion)
@@ -2092,23 +2172,31 @@ func (item *UAItem) parseCH() *UAItem {
 			}
 		}
 	case UACpu:
-		archName := uaCh.architecture
+		archName := uaCh.Architecture
 		if archName != "" {
-			if uaCh.bitness == "64" {
+			if uaCh.Bitness == "64" {
 				archName += "64"
 			}
-			item.data = parseUA(archName+";", rgxMap[item.itemType])
+			item.data = rules.parseUA(archName+";", item.itemType)
+			item.data[Bitness] = uaCh.Bitness
+			if item.data[Bitness] == "" {
+				item.data[Bitness] = archBitness(item.data[Architecture])
+			}
+		}
+		if uaCh.Wow64 {
+			item.data[Wow64] = "true"
+			item.data[Bitness] = "64"
 		}
 
 	case UADevice:
-		if uaCh.mobile {
+		if uaCh.Mobile {
 			item.data[Type] = Mobile
 		}
-		if uaCh.model != "" {
-			item.data[Model] = uaCh.model
+		if uaCh.Model != "" {
+			item.data[Model] = uaCh.Model
 			if item.data[Type] == "" || item.data[Vendor] == "" {
 				reParse := map[string]string{}
-				reParse = parseUA("droid 9; "+uaCh.model+")", rgxMap[item.itemType])
+				reParse = rules.parseUA("droid 9; "+uaCh.Model+")", item.itemType)
 				if item.data[Type] == "" && reParse[Type] != "" {
 					item.data[Type] = reParse[Type]
 				}
@@ -2117,9 +2205,9 @@ func (item *UAItem) parseCH() *UAItem {
 				}
 			}
 		}
-		if len(item.uaCH.formFactors) > 0 {
+		if len(item.uaCH.FormFactors) > 0 {
 			var ff string
-			for _, formFactor := range item.uaCH.formFactors {
+			for _, formFactor := range item.uaCH.FormFactors {
 				ff = strMapper(formFactor, formFactorsMap)
 				if ff != "" {
 					break
@@ -2128,9 +2216,9 @@ func (item *UAItem) parseCH() *UAItem {
 			item.data[Type] = ff
 		}
 	case UAOS:
-		osName := uaCh.platform
+		osName := uaCh.Platform
 		if osName != "" {
-			osVersion := uaCh.platformVer
+			osVersion := uaCh.PlatformVersion
 			if osName == Windows {
 				v, _ := strconv.Atoi(majorize(osVersion))
 				if v >= 13 {
@@ -2141,10 +2229,11 @@ func (item *UAItem) parseCH() *UAItem {
 			}
 			item.data[Name] = osName
 			item.data[Version] = osVersion
+			item.data[Platform] = osName
 		}
 
 		// Xbox-Specific Detection
-		if item.data[Name] == Windows && uaCh.model == Xbox {
+		if item.data[Name] == Windows && uaCh.Model == Xbox {
 			item.data[Name] = Xbox
 			item.data[Version] = ""
 		}
@@ -2154,42 +2243,97 @@ func (item *UAItem) parseCH() *UAItem {
 
 func (item *UAItem) parseUA() *UAItem {
 	if item.itemType != UAResult {
-		item.data = parseUA(item.ua, item.rgxMap[item.itemType])
+		if item.text == nil {
+			item.text = newUAText(item.ua)
+		}
+		item.data, item.match, item.err = item.rules.match(item.text, item.itemType, item.budget)
 	}
 	if item.itemType == UABrowser {
 		originVersion := item.data[Version]
 		item.data[Version] = originVersion
 		item.data[Major] = majorize(item.data[Version])
 	}
+	if item.itemType == UACpu {
+		if bitness := archBitness(item.data[Architecture]); bitness != "" {
+			item.data[Bitness] = bitness
+		}
+		// the regexp is slow to fail, so look for the word first
+		if (!item.text.ascii || strings.Contains(item.text.lower, "wow64")) && wow64Reg.MatchString(item.ua) {
+			item.data[Wow64] = "true"
+		}
+	}
+	if item.itemType == UAOS {
+		if platform := osPlatform(item.data[Name]); platform != "" {
+			item.data[Platform] = platform
+		}
+	}
 	return item
 }
 
+// archBitness returns the bitness of a CPU architecture as read from the
+// UA, or "" if it isn't known.
+func archBitness(arch string) string {
+	switch {
+	case arch == "":
+		return ""
+	case strings.HasSuffix(arch, "64"):
+		return "64"
+	case arch == "ia32" || arch == "armhf" || arch == "arm" || arch == "ppc" || arch == "avr" || arch == "68k":
+		return "32"
+	}
+	return ""
+}
+
+// osPlatforms maps the lowercased OS names found in UAs to the platforms of
+// Sec-CH-UA-Platform.
+var osPlatforms = map[string]string{
+	"windows": Windows, "macos": "macOS", "ios": "iOS",
+	"android": "Android", "android-x86": "Android",
+	"chrome os": "Chrome OS", "chromium os": "Chromium OS",
+	"linux": "Linux", "arch": "Linux", "centos": "Linux", "debian": "Linux", "deepin": "Linux",
+	"elementary os": "Linux", "fedora": "Linux", "gentoo": "Linux", "joli": "Linux", "kubuntu": "Linux",
+	"linpus": "Linux", "linspire": "Linux", "mandriva": "Linux", "manjaro": "Linux", "mint": "Linux",
+	"pclinuxos": "Linux", "raspbian": "Linux", "red hat": "Linux", "redhat": "Linux", "sabayon": "Linux",
+	"slackware": "Linux", "suse": "Linux", "ubuntu": "Linux", "zenwalk": "Linux",
+}
+
+// osPlatform returns the platform of an OS, or "" if it has none of its own.
+func osPlatform(os string) string {
+	return osPlatforms[strings.ToLower(os)]
+}
+
 func (item *UAItem) getData() map[string]string {
 	return item.data
 }
 
+// UAParser parses one UA at a time through a chaining API on top of a
+// Parser. The components it parses are remembered until its input or
+// configuration changes. A UAParser must not be modified while it is used
+// by other goroutines; share a Parser instead.
 type UAParser struct {
 	ua       string
 	httpUACH ClientHints
 	withCH   bool
-	regexMap map[string][]regexItem
+	parser   *Parser
+
+	mu sync.Mutex // guards in
+	in *input
 }
 
+var defaultParser = NewParser()
+
 func NewUAParser(ua string) *UAParser {
-	if len(ua) >= UAMaxLength {
-		ua = ""
-		slog.Warn("User-Agent string is too long, it will be ignored")
-	}
 	return &UAParser{
-		ua:       ua,
+		ua:       checkUA(ua),
 		withCH:   false,
 		httpUACH: ClientHints{},
-		regexMap: regexMap,
+		parser:   defaultParser,
 	}
 }
 
 func (p *UAParser) WithUA(ua string) *UAParser {
 	p.ua = ua
+	p.reset()
 	return p
 }
 
@@ -2198,52 +2342,106 @@ func (p *UAParser) WithHeaders(headers map[string]string) *UAParser {
 		p.withCH = true
 	}
 
+	// lowercase the keys into a copy; the caller's map is left alone
+	lowered := make(map[string]string, len(headers))
 	for k, v := range headers {
-		headers[strings.ToLower(k)] = v
+		lowered[strings.ToLower(k)] = v
 	}
+	headers = lowered
 
 	p.httpUACH = NewClientHints(headers)
 	if ua, ok := headers[UserAgent]; ok && p.ua == "" && len(ua) <= UAMaxLength {
 		p.ua = ua
 	}
+	p.reset()
 	return p
 }
 
-func (p *UAParser) WithExtensions(extensions map[string][]regexItem) *UAParser {
-	mergedMap := make(map[string][]regexItem)
-	if extensions != nil && len(extensions) > 0 {
-		for key, value := range regexMap {
-			if extValue, exists := extensions[key]; exists {
-				extensions[key] = append(extValue, value...)
-			} else {
-				extensions[key] = value
-			}
-		}
-		mergedMap = extensions
-	} else {
-		mergedMap = regexMap
-	}
-	p.regexMap = mergedMap
+// WithClientHints makes the parser use ch, e.g. read by ClientHintsFromJSON,
+// together with the UA.
+func (p *UAParser) WithClientHints(ch ClientHints) *UAParser {
+	p.httpUACH, p.withCH = ch, true
+	p.reset()
 	return p
 }
 
-func (p *UAParser) getData(itemType string) map[string]string {
-	if len(p.ua) < UAMinLength && !p.withCH {
-		return make(map[string]string)
-	}
+// WithRules replaces the rule set used by the parser, e.g. with one read by
+// LoadRulesFile. Extensions are applied on top of it. A nil rules restores
+// the embedded default set.
+func (p *UAParser) WithRules(rules *Rules) *UAParser {
+	return p.configure(WithRules(rules))
+}
 
-	uaItem := NewUAItem(itemType, p.ua, p.regexMap, p.httpUACH)
-	var data map[string]string
-	if p.withCH {
-		data = uaItem.parseUA().parseCH().getData()
-	} else {
-		data = uaItem.parseUA().getData()
+// WithRuleSet makes the parser use whatever rules the RuleSet holds at the
+// time of parsing. Each call to Browser, Result, etc. works on a single
+// snapshot, so a concurrent Swap or Reload never mixes two rule sets.
+func (p *UAParser) WithRuleSet(ruleSet *RuleSet) *UAParser {
+	return p.configure(WithRuleSet(ruleSet))
+}
+
+// WithExtensions adds rules taking precedence over the parser's rules. The
+// extensions are never modified, so package-level ones such as Crawlers can
+// be passed by any number of goroutines.
+func (p *UAParser) WithExtensions(extensions Extension) *UAParser {
+	return p.configure(WithExtensions(extensions))
+}
+
+// WithMerge merges ext into the parser's rules as told by mode, after the
+// extensions set before. See Rules.Merge.
+func (p *UAParser) WithMerge(mode MergeMode, ext Extension) *UAParser {
+	return p.configure(WithMerge(mode, ext))
+}
+
+// WithBudget sets the time budget of each parse. Browser, CPU, etc. stop
+// matching when it runs out and return what they found so far; Parse also
+// reports the *BudgetError.
+func (p *UAParser) WithBudget(budget Budget) *UAParser {
+	return p.configure(WithBudget(budget))
+}
+
+// WithCache makes Result and Parse look up and store their results in
+// cache. A nil cache disables caching.
+func (p *UAParser) WithCache(cache *ResultCache) *UAParser {
+	return p.configure(WithCache(cache))
+}
+
+// WithDeviceNames makes Device and Result fill in the marketing names of
+// devices from names. A nil names disables the lookup.
+func (p *UAParser) WithDeviceNames(names *DeviceNames) *UAParser {
+	return p.configure(WithDeviceNames(names))
+}
+
+// configure replaces the Parser with a modified copy, since Parsers are
+// immutable.
+func (p *UAParser) configure(opts ...Option) *UAParser {
+	p.parser = p.parser.with(opts...)
+	p.reset()
+	return p
+}
+
+// reset drops the components parsed so far.
+func (p *UAParser) reset() {
+	p.mu.Lock()
+	p.in = nil
+	p.mu.Unlock()
+}
+
+// input returns the state of the current UA and headers.
+func (p *UAParser) input() *input {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.in == nil {
+		p.in = &input{ua: p.ua, hints: p.httpUACH, withCH: p.withCH}
 	}
-	return data
+	return p.in
 }
 
 func (p *UAParser) Browser() IBrowser {
-	data := p.getData(UABrowser)
+	browser, _ := p.parser.browser(p.input(), p.parser.rules(), p.parser.budget.start())
+	return browser
+}
+
+func newBrowser(data map[string]string) IBrowser {
 	return IBrowser{
 		Name:    data[Name],
 		Version: data[Version],
@@ -2253,14 +2451,24 @@ func (p *UAParser) Browser() IBrowser {
 }
 
 func (p *UAParser) CPU() ICpu {
-	data := p.getData(UACpu)
+	cpu, _ := p.parser.cpu(p.input(), p.parser.rules(), p.parser.budget.start())
+	return cpu
+}
+
+func newCpu(data map[string]string) ICpu {
 	return ICpu{
 		Architecture: data[Architecture],
+		Bitness:      data[Bitness],
+		Wow64:        data[Wow64] == "true",
 	}
 }
 
 func (p *UAParser) Device() IDevice {
-	data := p.getData(UADevice)
+	device, _ := p.parser.device(p.input(), p.parser.rules(), p.parser.budget.start())
+	return device
+}
+
+func newDevice(data map[string]string) IDevice {
 	return IDevice{
 		Type:   data[Type],
 		Vendor: data[Vendor],
@@ -2269,7 +2477,11 @@ func (p *UAParser) Device() IDevice {
 }
 
 func (p *UAParser) Engine() IEngine {
-	data := p.getData(UAEngine)
+	engine, _ := p.parser.engine(p.input(), p.parser.rules(), p.parser.budget.start())
+	return engine
+}
+
+func newEngine(data map[string]string) IEngine {
 	return IEngine{
 		Name:    data[Name],
 		Version: data[Version],
@@ -2277,20 +2489,26 @@ func (p *UAParser) Engine() IEngine {
 }
 
 func (p *UAParser) Os() IOs {
-	data := p.getData(UAOS)
+	o, _ := p.parser.os(p.input(), p.parser.rules(), p.parser.budget.start())
+	return o
+}
+
+func newOs(data map[string]string) IOs {
 	return IOs{
-		Name:    data[Name],
-		Version: data[Version],
+		Platform: data[Platform],
+		Name:     data[Name],
+		Version:  data[Version],
 	}
 }
 
 func (p *UAParser) Result() IResult {
-	return IResult{
-		UA:      p.ua,
-		Browser: p.Browser(),
-		Engine:  p.Engine(),
-		Os:      p.Os(),
-		Device:  p.Device(),
-		Cpu:     p.CPU(),
-	}
+	result, _ := p.Parse()
+	return result
+}
+
+// Parse parses every component like Result, in a single pass sharing the
+// preparation of the UA. If the budget runs out it stops and returns the
+// components parsed so far together with a *BudgetError.
+func (p *UAParser) Parse() (IResult, error) {
+	return p.parser.parse(p.input())
 }
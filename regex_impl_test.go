@@ -412,6 +412,10 @@ func TestMapCHHeaders(t *testing.T) {
 
 		uapUnknown := NewUAParser("").WithHeaders(headersUnknown).Result()
 		assert.Equal(t, "", uapUnknown.Device.Type)
+
+		// form factors missing from the map fall back to no type
+		uapOther := NewUAParser("").WithHeaders(map[string]string{"sec-ch-ua-form-factors": "\"Foldable\""}).Result()
+		assert.Equal(t, "", uapOther.Device.Type)
 	})
 
 	t.Run("Avoid error on headers variation", func(t *testing.T) {
@@ -747,3 +751,70 @@ func TestMemoryUseWhenAllRegexLoaded(t *testing.T) {
 
 	t.Logf("Memory use: %d KB", heapDelta(heapSize)) // < 2.5MB
 }
+
+func TestUAParser_Memo(t *testing.T) {
+	var calls int
+	rule := NewRule(`(?i)(memoapp)\/([\w\.]+)`).
+		Output(Name, "$1").
+		Output(Version, "$2").
+		Mapper(Name, func(s string) string {
+			calls++
+			return s
+		}).
+		MustBuild()
+	ext, err := NewExtension(map[string][]Rule{UABrowser: {rule}})
+	assert.NoError(t, err)
+
+	parser := NewUAParser("Mozilla/5.0 (Linux) MemoApp/1.0").WithExtensions(ext)
+	assert.Equal(t, "MemoApp", parser.Browser().Name)
+	assert.Equal(t, "MemoApp", parser.Browser().Name)
+	assert.Equal(t, "MemoApp", parser.Result().Browser.Name)
+	assert.Equal(t, 1, calls)
+
+	// changing the input invalidates the memo
+	assert.Equal(t, "2.0", parser.WithUA("Mozilla/5.0 (Linux) MemoApp/2.0").Browser().Version)
+	assert.Equal(t, 2, calls)
+	parser.WithHeaders(map[string]string{"sec-ch-ua-mobile": "?1"})
+	assert.Equal(t, "mobile", parser.Device().Type)
+	assert.Equal(t, "2.0", parser.Browser().Version)
+	assert.Equal(t, 3, calls)
+	assert.Empty(t, parser.WithExtensions(nil).Browser().Name)
+	assert.Equal(t, 3, calls)
+}
+
+func TestPlatformAndBitness(t *testing.T) {
+	wow64 := NewUAParser("Mozilla/5.0 (Windows NT 10.0; WOW64; rv:115.0) Gecko/20100101 Firefox/115.0").Result()
+	assert.Equal(t, ICpu{Architecture: "amd64", Bitness: "64", Wow64: true}, wow64.Cpu)
+	assert.Equal(t, "Windows", wow64.Os.Platform)
+
+	win64 := NewUAParser(cacheChromeUA).Result()
+	assert.Equal(t, ICpu{Architecture: "amd64", Bitness: "64"}, win64.Cpu)
+
+	i686 := NewUAParser("Mozilla/5.0 (X11; Ubuntu; Linux i686; rv:109.0) Gecko/20100101 Firefox/119.0").Result()
+	assert.Equal(t, ICpu{Architecture: "ia32", Bitness: "32"}, i686.Cpu)
+	assert.Equal(t, IOs{Platform: "Linux", Name: "Ubuntu"}, i686.Os)
+
+	mac := NewUAParser(cacheSafariUA).Os()
+	assert.Equal(t, "macOS", mac.Platform)
+	assert.Empty(t, NewUAParser("Mozilla/5.0 (PlayStation 4 11.00) AppleWebKit/605.1.15").Os().Platform)
+
+	// client hints
+	hinted := NewUAParser(cacheChromeUA).WithHeaders(map[string]string{
+		CHHeader:            `"Chromium";v="120", "Google Chrome";v="120"`,
+		CHHeaderFullVersion: `"120.0.6099.130"`,
+		CHHeaderPlatform:    `"Chrome OS"`,
+		CHHeaderArch:        `"x86"`,
+		CHHeaderBitness:     `"64"`,
+		CHHeaderWow64:       "?1",
+	}).Result()
+	assert.Equal(t, ICpu{Architecture: "amd64", Bitness: "64", Wow64: true}, hinted.Cpu)
+	assert.Equal(t, "Chrome OS", hinted.Os.Platform)
+	assert.Equal(t, "120.0.6099.130", hinted.Browser.Version)
+
+	arm := NewUAParser(cacheChromeUA).WithHeaders(map[string]string{
+		CHHeaderArch:    `"arm"`,
+		CHHeaderBitness: `"64"`,
+		CHHeaderWow64:   "?0",
+	}).CPU()
+	assert.Equal(t, ICpu{Architecture: "arm64", Bitness: "64"}, arm)
+}
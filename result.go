@@ -14,12 +14,18 @@ type IBrowser struct {
 
 type ICpu struct {
 	Architecture string `json:"architecture,omitempty"`
+	Bitness      string `json:"bitness,omitempty"` // 32 or 64
+	Wow64        bool   `json:"wow64,omitempty"`   // 32-bit browser on 64-bit Windows
 }
 
 type IDevice struct {
 	Type   string `json:"type,omitempty"` // Mobile, Desktop, Bot, Console
 	Model  string `json:"model,omitempty"`
 	Vendor string `json:"vendor,omitempty"`
+	// MarketingName and ReleaseYear are looked up in the DeviceNames set by
+	// WithDeviceNames, e.g. Galaxy S21 5G for SM-G991B.
+	MarketingName string `json:"marketingName,omitempty"`
+	ReleaseYear   int    `json:"releaseYear,omitempty"`
 }
 
 type IEngine struct {
@@ -33,6 +39,33 @@ type IOs struct {
 	Version  string `json:"version,omitempty"`
 }
 
+// IAutomation tells whether a UA comes from a headless browser or an
+// automation framework, see DetectAutomation.
+type IAutomation struct {
+	Framework string `json:"framework,omitempty"`
+	// Confidence is from 0 for no sign of automation to 1.
+	Confidence float64 `json:"confidence,omitempty"`
+	// Signals are the kinds of evidence found, each listed once.
+	Signals []string `json:"signals,omitempty"`
+}
+
+// IInApp tells whether a UA comes from a WebView and which app hosts it,
+// see DetectInApp. The fields after AppVersion are only known for apps
+// reporting them, such as Facebook's.
+type IInApp struct {
+	WebView       string `json:"webview,omitempty"` // WebViewAndroid or WebViewWK
+	App           string `json:"app,omitempty"`
+	AppVersion    string `json:"appVersion,omitempty"`
+	AppBuild      string `json:"appBuild,omitempty"`
+	Device        string `json:"device,omitempty"` // e.g. iPhone12,5
+	System        string `json:"system,omitempty"`
+	SystemVersion string `json:"systemVersion,omitempty"`
+	FormFactor    string `json:"formFactor,omitempty"` // e.g. phone or tablet
+	Locale        string `json:"locale,omitempty"`
+	Carrier       string `json:"carrier,omitempty"`
+	Network       string `json:"network,omitempty"` // e.g. WIFI or 4G
+}
+
 type IResult struct {
 	UA      string   `json:"ua"`
 	Browser IBrowser `json:"browser"`
@@ -40,4 +73,13 @@ type IResult struct {
 	Device  IDevice  `json:"device"`
 	Engine  IEngine  `json:"engine"`
 	Os      IOs      `json:"os"`
+	// Reduced is set for reduced User-Agent strings, see IsFrozenUA.
+	Reduced bool `json:"reduced,omitempty"`
+	// Unreliable lists the fields holding values frozen by User-Agent
+	// reduction, e.g. FieldOSVersion, that client hints didn't provide.
+	Unreliable []string `json:"unreliable,omitempty"`
+	// Automation reports the automation framework driving the browser.
+	Automation IAutomation `json:"automation,omitzero"`
+	// InApp reports WebViews and the apps hosting them.
+	InApp IInApp `json:"inapp,omitzero"`
 }
@@ -0,0 +1,166 @@
+package uaparser
+
+import (
+	"errors"
+	"fmt"
+	"github.com/dlclark/regexp2"
+	"regexp"
+	"slices"
+	"strconv"
+)
+
+var (
+	ErrNoPatterns       = errors.New("uaparser: rule has no patterns")
+	ErrNoOutput         = errors.New("uaparser: rule has no output")
+	ErrUnknownField     = errors.New("uaparser: unknown output field")
+	ErrUnknownComponent = errors.New("uaparser: unknown component")
+)
+
+// ruleFields lists the output fields understood by each component.
+var ruleFields = map[string][]string{
+	UABrowser: {Name, Version, Major, Type},
+	UACpu:     {Architecture},
+	UADevice:  {Type, Model, Vendor},
+	UAEngine:  {Name, Version},
+	UAOS:      {Name, Version},
+}
+
+// Rule is a validated parsing rule. Rules are created with NewRule and
+// grouped into extensions with NewExtension.
+type Rule struct {
+	item regexItem
+}
+
+// Extension holds rules by component, to be merged into a rule set, e.g.
+// CLIs or the result of NewExtension.
+type Extension map[string][]regexItem
+
+// RuleBuilder assembles a Rule. Errors are collected while building and
+// reported by Build.
+type RuleBuilder struct {
+	patterns []string
+	output   map[string]string
+	mappers  []mapperItem
+	errs     []error
+}
+
+// NewRule starts a rule that tries the given patterns in order. The first
+// matching pattern wins and its capture groups are available to the output
+// templates as $1, $2, ...
+func NewRule(patterns ...string) *RuleBuilder {
+	return &RuleBuilder{
+		patterns: patterns,
+		output:   make(map[string]string),
+	}
+}
+
+// Output sets the template of an output field, e.g. Output(Name, "$1").
+// Templates without a $n reference are used as literal values.
+func (b *RuleBuilder) Output(field, template string) *RuleBuilder {
+	b.output[field] = template
+	return b
+}
+
+// Mapper registers a function that post-processes an output field after
+// the templates have been expanded. Mappers run in registration order.
+func (b *RuleBuilder) Mapper(field string, fn func(string) string) *RuleBuilder {
+	if field == "" || fn == nil {
+		b.errs = append(b.errs, fmt.Errorf("uaparser: invalid mapper for field %q", field))
+		return b
+	}
+	b.mappers = append(b.mappers, mapperItem{field: field, fn: fn})
+	return b
+}
+
+// Build validates the rule. Every pattern must compile and every $n
+// reference in the output templates must point to an existing group.
+func (b *RuleBuilder) Build() (Rule, error) {
+	errs := slices.Clone(b.errs)
+	if len(b.patterns) == 0 {
+		errs = append(errs, ErrNoPatterns)
+	}
+	if len(b.output) == 0 {
+		errs = append(errs, ErrNoOutput)
+	}
+	for _, pattern := range b.patterns {
+		groups, err := countGroups(pattern)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("uaparser: invalid pattern %q: %w", pattern, err))
+			continue
+		}
+		for field, template := range b.output {
+			for _, ref := range dollarReplaceReg.FindAllString(template, -1) {
+				if idx, _ := strconv.Atoi(ref[1:]); idx > groups {
+					errs = append(errs, fmt.Errorf("uaparser: output %q references %s but pattern %q has %d groups",
+						field, ref, pattern, groups))
+				}
+			}
+		}
+	}
+	if err := errors.Join(errs...); err != nil {
+		return Rule{}, err
+	}
+
+	return Rule{
+		item: regexItem{
+			patterns:    append([]string(nil), b.patterns...),
+			output:      deepCopyMap(b.output),
+			mapperItems: append([]mapperItem(nil), b.mappers...),
+		},
+	}, nil
+}
+
+// MustBuild is like Build but panics if the rule is invalid. It simplifies
+// the initialization of package-level rules.
+func (b *RuleBuilder) MustBuild() Rule {
+	rule, err := b.Build()
+	if err != nil {
+		panic(err)
+	}
+	return rule
+}
+
+// NewExtension groups rules by component (UABrowser, UACpu, UADevice,
+// UAEngine or UAOS). The result can be passed to UAParser.WithExtensions
+// and takes precedence over the built-in rules.
+func NewExtension(rules map[string][]Rule) (Extension, error) {
+	extension := make(Extension, len(rules))
+	var errs []error
+	for component, list := range rules {
+		fields, ok := ruleFields[component]
+		if !ok {
+			errs = append(errs, fmt.Errorf("%w: %q", ErrUnknownComponent, component))
+			continue
+		}
+		for _, rule := range list {
+			for field := range rule.item.output {
+				if !slices.Contains(fields, field) {
+					errs = append(errs, fmt.Errorf("%w: %q in %s rule", ErrUnknownField, field, component))
+				}
+			}
+			for _, mp := range rule.item.mapperItems {
+				if !slices.Contains(fields, mp.field) {
+					errs = append(errs, fmt.Errorf("%w: mapper %q in %s rule", ErrUnknownField, mp.field, component))
+				}
+			}
+			extension[component] = append(extension[component], rule.item)
+		}
+	}
+	if err := errors.Join(errs...); err != nil {
+		return nil, err
+	}
+	return extension, nil
+}
+
+// countGroups compiles the pattern the same way applyPattern does and
+// returns the number of capture groups.
+func countGroups(pattern string) (int, error) {
+	if re, err := regexp.Compile(pattern); err == nil {
+		return re.NumSubexp(), nil
+	}
+	re2, err := regexp2.Compile(pattern, 0)
+	if err != nil {
+		return 0, err
+	}
+	return len(re2.GetGroupNumbers()) - 1, nil
+}
@@ -0,0 +1,81 @@
+package uaparser
+
+import (
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"strings"
+	"testing"
+)
+
+func TestRuleBuilder_Build(t *testing.T) {
+	rule, err := NewRule(`(?i)(kioskapp)\/([\w\.]+)`).
+		Output(Name, "Kiosk $1").
+		Output(Version, "$2").
+		Output(Type, InApp).
+		Mapper(Name, strings.ToUpper).
+		Build()
+	assert.NoError(t, err)
+
+	ext, err := NewExtension(map[string][]Rule{UABrowser: {rule}})
+	assert.NoError(t, err)
+
+	browser := NewUAParser("Mozilla/5.0 (Linux; Android 12) KioskApp/3.2.1").WithExtensions(ext).Browser()
+	assert.Equal(t, IBrowser{Name: "KIOSK KIOSKAPP", Version: "3.2.1", Major: "3", Type: InApp}, browser)
+
+	// extensions can be held by their exported type, like the built-in ones
+	extensions := []Extension{CLIs, ext}
+	parser := NewParser(WithExtensions(extensions[0]), WithMerge(Prepend, extensions[1]))
+	result, err := parser.Parse("curl/8.4.0")
+	assert.NoError(t, err)
+	assert.Equal(t, "curl", result.Browser.Name)
+	result, err = parser.Parse("KioskApp/3.2.1")
+	assert.NoError(t, err)
+	assert.Equal(t, "KIOSK KIOSKAPP", result.Browser.Name)
+}
+
+func TestRuleBuilder_Lookaround(t *testing.T) {
+	rule, err := NewRule(`(?i)partnersdk\/([\d\.]+)(?!.+beta)`).
+		Output(Name, "Partner SDK").
+		Output(Version, "$1").
+		Build()
+	assert.NoError(t, err)
+
+	ext, err := NewExtension(map[string][]Rule{UABrowser: {rule}})
+	assert.NoError(t, err)
+
+	parser := NewUAParser("").WithExtensions(ext)
+	assert.Equal(t, "Partner SDK", parser.WithUA("PartnerSDK/1.4 (Android)").Browser().Name)
+	assert.NotEqual(t, "Partner SDK", parser.WithUA("PartnerSDK/1.4 (Android) beta").Browser().Name)
+}
+
+func TestRuleBuilder_Errors(t *testing.T) {
+	_, err := NewRule().Output(Name, "x").Build()
+	assert.True(t, errors.Is(err, ErrNoPatterns))
+
+	_, err = NewRule(`foo`).Build()
+	assert.True(t, errors.Is(err, ErrNoOutput))
+
+	_, err = NewRule(`(?i)(foo`).Output(Name, "$1").Build()
+	assert.ErrorContains(t, err, "invalid pattern")
+
+	_, err = NewRule(`(foo)\/(\d+)`).Output(Version, "$3").Build()
+	assert.ErrorContains(t, err, "references $3")
+
+	_, err = NewRule(`foo`).Output(Name, "Foo").Mapper(Name, nil).Build()
+	assert.ErrorContains(t, err, "invalid mapper")
+
+	assert.Panics(t, func() { NewRule(`(`).Output(Name, "x").MustBuild() })
+}
+
+func TestNewExtension_Errors(t *testing.T) {
+	rule := NewRule(`(foo)`).Output(Model, "$1").MustBuild()
+
+	_, err := NewExtension(map[string][]Rule{"gpu": {rule}})
+	assert.True(t, errors.Is(err, ErrUnknownComponent))
+
+	_, err = NewExtension(map[string][]Rule{UABrowser: {rule}})
+	assert.True(t, errors.Is(err, ErrUnknownField))
+
+	_, err = NewExtension(map[string][]Rule{UADevice: {rule}})
+	assert.NoError(t, err)
+}
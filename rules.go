@@ -0,0 +1,216 @@
+package uaparser
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// Rules is a complete set of parsing rules, keyed by component. A Rules
+// value is never modified after it has been created, so it can be shared
+// by any number of parsers.
+//
+// Compiled patterns are cached per rule set and released together with it,
+// which keeps memory bounded when rule sets are reloaded.
+type Rules struct {
+	id    uint64 // identifies the rules in result cache keys
+	items map[string][]regexItem
+	cache *regexCache
+	// base is set on rules created by Merge, the rules merged into.
+	base *Rules
+	// root is the rule set whose prefilter index covers part of the items;
+	// rootAt holds where its rules of a component start in items, or -1.
+	root   *Rules
+	rootAt map[string]int
+
+	indexOnce sync.Once
+	index     *prefilter
+}
+
+var defaultRules = newRules(regexMap)
+
+var rulesID atomic.Uint64
+
+// mergedRules remembers recent merges, so that parsers created per request
+// with the same extensions, e.g. by UAParser.WithExtensions, share the
+// patterns compiled for them.
+var mergedRules = newLRU[mergeKey, mergedEntry](256, 0)
+
+type mergeKey struct {
+	base uint64
+	ext  string // see extensionKey
+}
+
+type mergedEntry struct {
+	rules *Rules
+	// ext keeps the rules identified in the key allocated, so their
+	// addresses can't be reused by other rules while the entry lives
+	ext Extension
+}
+
+func newRules(items map[string][]regexItem) *Rules {
+	r := &Rules{id: rulesID.Add(1), items: items, cache: &regexCache{}}
+	r.root, r.rootAt = r, make(map[string]int, len(items))
+	for component := range items {
+		r.rootAt[component] = 0
+	}
+	return r
+}
+
+// DefaultRules returns the rule set compiled into the package.
+func DefaultRules() *Rules {
+	return defaultRules
+}
+
+// Len returns the number of rules defined for the component.
+func (r *Rules) Len(component string) int {
+	return len(r.items[component])
+}
+
+// MergeMode tells how Merge combines the rules of a component.
+type MergeMode int
+
+const (
+	// Prepend tries the merged rules before the existing ones.
+	Prepend MergeMode = iota
+	// Append tries the merged rules after the existing ones.
+	Append
+	// Replace uses the merged rules instead of the existing ones. Components
+	// without merged rules are kept.
+	Replace
+)
+
+// Merge returns a new rule set combining the rules of r with those of ext
+// per component as told by mode. Neither r nor ext is modified, and the
+// result never shares writable storage with them, so it is safe to merge
+// package-level extensions such as Crawlers concurrently. To merge
+// components differently, call Merge once per mode:
+//
+//	rules := uaparser.DefaultRules().
+//		Merge(uaparser.Prepend, uaparser.Crawlers).
+//		Merge(uaparser.Replace, devices)
+//
+// The rules of the root rule set, e.g. DefaultRules, keep running on its
+// compiled patterns, and the merged rules are compiled into a cache of the
+// result, released together with it. Recent results are remembered, so
+// merging the same extension value into r again returns the same rules,
+// with their patterns compiled already.
+func (r *Rules) Merge(mode MergeMode, ext Extension) *Rules {
+	if len(ext) == 0 {
+		return r
+	}
+	key := mergeKey{base: r.id, ext: extensionKey(mode, ext)}
+	if entry, ok := mergedRules.get(key); ok {
+		return entry.rules
+	}
+	m := r.merge(mode, ext)
+	mergedRules.put(key, mergedEntry{rules: m, ext: ext})
+	return m
+}
+
+// extensionKey identifies the merge of ext by mode and the rules merged, by
+// the address of the first rule of each component. Maps of extensions are
+// copied by the parser options, their rule arrays are not, so merging the
+// same extension value gives the same key.
+func extensionKey(mode MergeMode, ext Extension) string {
+	keys := make([]string, 0, len(ext))
+	for component, items := range ext {
+		if len(items) > 0 {
+			keys = append(keys, fmt.Sprintf("%s:%p:%d", component, &items[0], len(items)))
+		}
+	}
+	slices.Sort(keys)
+	return strconv.Itoa(int(mode)) + "=" + strings.Join(keys, ",")
+}
+
+func (r *Rules) merge(mode MergeMode, ext Extension) *Rules {
+	m := &Rules{
+		id:     rulesID.Add(1),
+		items:  make(map[string][]regexItem, len(r.items)+len(ext)),
+		cache:  &regexCache{},
+		base:   r,
+		root:   r.root,
+		rootAt: make(map[string]int, len(r.rootAt)),
+	}
+	for component, items := range r.items {
+		m.items[component] = slices.Clip(items)
+	}
+	for component, at := range r.rootAt {
+		m.rootAt[component] = at
+	}
+	for component, items := range ext {
+		if len(items) == 0 {
+			continue
+		}
+		switch mode {
+		case Prepend:
+			m.items[component] = slices.Concat(items, r.items[component])
+			if at, ok := r.rootAt[component]; ok && at >= 0 {
+				m.rootAt[component] = at + len(items)
+			}
+		case Append:
+			m.items[component] = slices.Concat(r.items[component], items)
+		case Replace:
+			m.items[component] = slices.Clip(items)
+			m.rootAt[component] = -1
+		}
+	}
+	return m
+}
+
+// merged reports whether rule i of component was merged into the rules by
+// Merge rather than coming from the root rule set.
+func (r *Rules) merged(component string, i int) bool {
+	at, ok := r.rootAt[component]
+	return !ok || at < 0 || i < at || i >= at+len(r.root.items[component])
+}
+
+// extend returns a rule set where the extension rules take precedence over
+// the rules of r.
+func (r *Rules) extend(extensions Extension) *Rules {
+	return r.Merge(Prepend, extensions)
+}
+
+func (r *Rules) parseUA(ua string, component string) map[string]string {
+	result, _, _ := r.match(newUAText(ua), component, nil)
+	return result
+}
+
+// match runs the rules of a component against ua and returns the result of
+// the first matching rule. It stops with a *BudgetError when the budget runs
+// out.
+func (r *Rules) match(text *uaText, component string, budget *parseBudget) (map[string]string, *ruleMatch, error) {
+	ua := text.ua
+	c := r.newCandidates(text, component)
+	for i, regItem := range r.items[component] {
+		for j, pattern := range regItem.patterns {
+			if !c.may(i, j, pattern) {
+				continue
+			}
+			if budget.exceeded() {
+				return make(map[string]string), nil, budget.error(component, "")
+			}
+			cache := r.cache
+			if !r.merged(component, i) {
+				cache = r.root.cache
+			}
+			result, groups, err := applyPattern(cache, ua, pattern, regItem.output, budget.timeout())
+			if err != nil {
+				return make(map[string]string), nil, budget.error(component, pattern)
+			}
+			if groups != nil {
+				// Apply mapping functions
+				for _, mp := range regItem.mapperItems {
+					if mp.field != "" {
+						result[mp.field] = mp.fn(result[mp.field])
+					}
+				}
+				return result, &ruleMatch{rule: i, pattern: j, groups: groups}, nil
+			}
+		}
+	}
+	return make(map[string]string), nil, nil
+}
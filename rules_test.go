@@ -0,0 +1,137 @@
+package uaparser
+
+import (
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"sync"
+	"testing"
+	"unsafe"
+)
+
+func TestRules_Merge(t *testing.T) {
+	custom := map[string][]regexItem{UABrowser: {{
+		patterns: []string{`(?i)(chrome)\/([\w\.]+)`},
+		output:   map[string]string{Name: "Custom"},
+	}}}
+	base := DefaultRules()
+	n := base.Len(UABrowser)
+
+	prepended := base.Merge(Prepend, custom)
+	require.Equal(t, n+1, prepended.Len(UABrowser))
+	assert.Equal(t, "Custom", NewUAParser(cacheChromeUA).WithRules(prepended).Browser().Name)
+	assert.True(t, prepended.merged(UABrowser, 0))
+	assert.False(t, prepended.merged(UABrowser, 1))
+
+	appended := base.Merge(Append, custom)
+	require.Equal(t, n+1, appended.Len(UABrowser))
+	assert.Equal(t, "Chrome", NewUAParser(cacheChromeUA).WithRules(appended).Browser().Name)
+	assert.True(t, appended.merged(UABrowser, n))
+
+	replaced := base.Merge(Replace, custom)
+	assert.Equal(t, 1, replaced.Len(UABrowser))
+	assert.Equal(t, base.Len(UAOS), replaced.Len(UAOS))
+	assert.Equal(t, "Custom", NewUAParser(cacheChromeUA).WithRules(replaced).Browser().Name)
+	assert.Empty(t, NewUAParser(cacheFirefoxUA).WithRules(replaced).Browser().Name)
+	assert.Equal(t, "Linux", NewUAParser(cacheFirefoxUA).WithRules(replaced).Os().Name)
+
+	// merges chain, and the rules merged into are never modified
+	chained := prepended.Merge(Append, CLIs)
+	assert.Equal(t, "curl", NewUAParser("curl/8.4.0").WithRules(chained).Browser().Name)
+	assert.Equal(t, n, base.Len(UABrowser))
+	assert.Equal(t, n+1, prepended.Len(UABrowser))
+	assert.Same(t, base, base.Merge(Prepend, nil))
+
+	// the same through parser options
+	parser := NewParser(WithMerge(Replace, custom), WithMerge(Append, CLIs))
+	result, err := parser.Parse(cacheChromeUA)
+	require.NoError(t, err)
+	assert.Equal(t, "Custom", result.Browser.Name)
+	result, err = parser.Parse("curl/8.4.0")
+	require.NoError(t, err)
+	assert.Equal(t, "curl", result.Browser.Name)
+}
+
+// Merged patterns are cached by the merged rules, not by the rules merged
+// into, so they are released together with them.
+func TestRules_MergeCache(t *testing.T) {
+	pattern := `(?i)\bmergecache\/`
+	merged := DefaultRules().Merge(Prepend, map[string][]regexItem{UABrowser: {{
+		patterns: []string{pattern},
+		output:   map[string]string{Name: "MergeCache"},
+	}}})
+	assert.Equal(t, "Chrome", NewUAParser(cacheChromeUA).WithRules(merged).Browser().Name)
+	assert.Equal(t, "MergeCache", NewUAParser("MergeCache/1.0").WithRules(merged).Browser().Name)
+
+	_, ok := merged.cache.patterns.Load(pattern)
+	assert.True(t, ok)
+	_, ok = defaultRules.cache.patterns.Load(pattern)
+	assert.False(t, ok)
+	// the default patterns stay in the cache of the default rules
+	_, ok = merged.cache.patterns.Load(defaultRules.items[UABrowser][0].patterns[0])
+	assert.False(t, ok)
+
+	// merging the same extension value again reuses the merged rules and
+	// their compiled patterns, even through the copies made by the options
+	assert.Same(t, DefaultRules().Merge(Prepend, Bots), DefaultRules().Merge(Prepend, Bots))
+	assert.Same(t, NewParser(WithExtensions(Bots)).rules(), NewParser(WithExtensions(Bots)).rules())
+	assert.NotSame(t, DefaultRules().Merge(Prepend, Bots), DefaultRules().Merge(Append, Bots))
+	assert.NotSame(t, DefaultRules().Merge(Prepend, Bots), DefaultRules().Merge(Prepend, CLIs))
+}
+
+// snapshotRules describes the rule slices of a rule map down to their
+// arrays, so appending to them in place shows up even within capacity.
+func snapshotRules(rules map[string][]regexItem) map[string]string {
+	snapshot := make(map[string]string, len(rules))
+	for component, items := range rules {
+		s := fmt.Sprintf("%p/%d/%d", unsafe.SliceData(items), len(items), cap(items))
+		for _, item := range items {
+			s += fmt.Sprintf("|%q%v%d", item.patterns, item.output, len(item.mapperItems))
+		}
+		snapshot[component] = s
+	}
+	return snapshot
+}
+
+// Run with -race: package-level rules and caller maps are shared by every
+// goroutine below and must only ever be read.
+func TestRules_GlobalsUnmodified(t *testing.T) {
+	before := make(map[string]map[string]string, len(builtinRules))
+	for name, rules := range builtinRules {
+		before[name] = snapshotRules(rules)
+	}
+	headers := map[string]string{
+		"User-Agent":         cacheChromeUA,
+		"Sec-CH-UA-Platform": `"Windows"`,
+	}
+	shared := NewParser(WithExtensions(Crawlers), WithCache(NewResultCache(16, 0)))
+
+	var wg sync.WaitGroup
+	for g := 0; g < 8; g++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for name, ext := range builtinRules {
+				if name == "default" {
+					continue
+				}
+				NewUAParser("curl/8.4.0").WithExtensions(ext).WithExtensions(Crawlers).Result()
+				NewUAParser("").WithHeaders(headers).WithMerge(Append, ext).Result()
+				DefaultRules().Merge(Replace, ext).Merge(Prepend, Bots)
+				_, err := NewParser(WithMerge(Append, ext), WithExtensions(Bots)).Parse(cacheFirefoxUA)
+				assert.NoError(t, err)
+				_, err = shared.Parse(cacheSafariUA)
+				assert.NoError(t, err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	for name, rules := range builtinRules {
+		assert.Equal(t, before[name], snapshotRules(rules), name)
+	}
+	assert.Equal(t, map[string]string{
+		"User-Agent":         cacheChromeUA,
+		"Sec-CH-UA-Platform": `"Windows"`,
+	}, headers)
+}
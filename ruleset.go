@@ -0,0 +1,117 @@
+package uaparser
+
+import (
+	"context"
+	"errors"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// RuleSet holds the rules of a long-running service and allows them to be
+// replaced while parsers are using them. Parses that already started finish
+// on the rules they began with; parses started after a Swap or Reload use the
+// new rules.
+type RuleSet struct {
+	current atomic.Pointer[Rules]
+
+	mu      sync.Mutex // serializes Reload
+	path    string
+	modTime time.Time
+}
+
+var ErrNoRuleSource = errors.New("uaparser: rule set has no source to reload from")
+
+// NewRuleSet returns a RuleSet holding rules. A nil rules holds the embedded
+// default set.
+func NewRuleSet(rules *Rules) *RuleSet {
+	if rules == nil {
+		rules = defaultRules
+	}
+	s := &RuleSet{}
+	s.current.Store(rules)
+	return s
+}
+
+// NewRuleSetFromFile loads the rules from path. Reload and Watch read the
+// same file again.
+func NewRuleSetFromFile(path string) (*RuleSet, error) {
+	s := &RuleSet{path: path}
+	if err := s.Reload(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// Rules returns the rules currently held.
+func (s *RuleSet) Rules() *Rules {
+	return s.current.Load()
+}
+
+// Swap atomically replaces the held rules and returns the previous ones.
+func (s *RuleSet) Swap(rules *Rules) *Rules {
+	if rules == nil {
+		rules = defaultRules
+	}
+	return s.current.Swap(rules)
+}
+
+// Reload reads the rule file again and swaps in its rules. If the file
+// cannot be loaded the current rules are kept and the error is returned.
+func (s *RuleSet) Reload() error {
+	if s.path == "" {
+		return ErrNoRuleSource
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	fi, err := os.Stat(s.path)
+	if err != nil {
+		return err
+	}
+	rules, err := LoadRulesFile(s.path)
+	if err != nil {
+		return err
+	}
+	s.current.Store(rules)
+	s.modTime = fi.ModTime()
+	return nil
+}
+
+// Watch polls the rule file every interval and reloads it when its
+// modification time changes, until ctx is done. Reload errors are passed to
+// onError, which may be nil.
+func (s *RuleSet) Watch(ctx context.Context, interval time.Duration, onError func(error)) error {
+	if s.path == "" {
+		return ErrNoRuleSource
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	var failedAt time.Time
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+
+		fi, err := os.Stat(s.path)
+		if err == nil {
+			s.mu.Lock()
+			changed := !fi.ModTime().Equal(s.modTime)
+			s.mu.Unlock()
+			// don't retry a broken file until it is modified again
+			if !changed || fi.ModTime().Equal(failedAt) {
+				continue
+			}
+			if err = s.Reload(); err != nil {
+				failedAt = fi.ModTime()
+			}
+		}
+		if err != nil && onError != nil {
+			onError(err)
+		}
+	}
+}
@@ -0,0 +1,99 @@
+package uaparser
+
+import (
+	"context"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+const kioskRulesV1 = "browser:\n  - patterns: ['(?i)(kioskapp)\\/([\\w\\.]+)']\n    output: {name: Kiosk, version: $2}\n"
+const kioskRulesV2 = "browser:\n  - patterns: ['(?i)(kioskapp)\\/([\\w\\.]+)']\n    output: {name: Kiosk App, version: $2}\n"
+
+func writeRules(t *testing.T, path, content string, modTime time.Time) {
+	t.Helper()
+	assert.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+	assert.NoError(t, os.Chtimes(path, modTime, modTime))
+}
+
+func TestRuleSet_Reload(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "rules.yaml")
+	writeRules(t, path, kioskRulesV1, time.Now().Add(-time.Hour))
+
+	rs, err := NewRuleSetFromFile(path)
+	assert.NoError(t, err)
+
+	parser := NewUAParser("KioskApp/1.2").WithRuleSet(rs)
+	assert.Equal(t, "Kiosk", parser.Browser().Name)
+
+	writeRules(t, path, kioskRulesV2, time.Now())
+	assert.NoError(t, rs.Reload())
+	assert.Equal(t, "Kiosk App", parser.Browser().Name)
+
+	// a broken file keeps the current rules
+	writeRules(t, path, "browser: [{patterns: ['(']}]", time.Now())
+	assert.Error(t, rs.Reload())
+	assert.Equal(t, "Kiosk App", parser.Browser().Name)
+
+	assert.True(t, errors.Is(NewRuleSet(nil).Reload(), ErrNoRuleSource))
+}
+
+func TestRuleSet_Watch(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "rules.yaml")
+	writeRules(t, path, kioskRulesV1, time.Now().Add(-time.Hour))
+
+	rs, err := NewRuleSetFromFile(path)
+	assert.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() { done <- rs.Watch(ctx, 5*time.Millisecond, nil) }()
+
+	writeRules(t, path, kioskRulesV2, time.Now())
+	assert.Eventually(t, func() bool {
+		return NewUAParser("KioskApp/1.2").WithRuleSet(rs).Browser().Name == "Kiosk App"
+	}, time.Second, 5*time.Millisecond)
+
+	cancel()
+	assert.ErrorIs(t, <-done, context.Canceled)
+}
+
+func TestRuleSet_SwapWhileParsing(t *testing.T) {
+	v1, err := LoadRules(strings.NewReader(kioskRulesV1))
+	assert.NoError(t, err)
+	v2, err := LoadRules(strings.NewReader(kioskRulesV2))
+	assert.NoError(t, err)
+
+	rs := NewRuleSet(v1)
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			name := NewUAParser("KioskApp/1.2").WithRuleSet(rs).WithExtensions(CLIs).Browser().Name
+			assert.Contains(t, []string{"Kiosk", "Kiosk App"}, name)
+		}()
+		go func(i int) {
+			defer wg.Done()
+			if i%2 == 0 {
+				rs.Swap(v2)
+			} else {
+				rs.Swap(v1)
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	rs.Swap(v2)
+	parser := NewUAParser("KioskApp/1.2").WithRuleSet(rs).WithExtensions(CLIs)
+	assert.Equal(t, "Kiosk App", parser.Browser().Name)
+	assert.Same(t, v2, rs.Swap(nil))
+	assert.Same(t, DefaultRules(), rs.Rules())
+	assert.Equal(t, "", parser.Browser().Name)
+	assert.Equal(t, "curl", parser.WithUA("curl/8.1.2").Browser().Name)
+}
@@ -0,0 +1,370 @@
+package uaparser
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// ErrMalformedHeader is matched by every HeaderError.
+var ErrMalformedHeader = errors.New("uaparser: malformed header")
+
+// HeaderError reports a client hint header that isn't a valid Structured
+// Field Value (RFC 8941) of the type the header is defined with.
+type HeaderError struct {
+	// Header is the lowercased name of the header.
+	Header string
+	// Value is the value of the header.
+	Value string
+	// Offset is the position in Value the error was found at.
+	Offset int
+	// Reason describes the error.
+	Reason string
+}
+
+func (e *HeaderError) Error() string {
+	return fmt.Sprintf("uaparser: header %s: %s at offset %d of %q", e.Header, e.Reason, e.Offset, e.Value)
+}
+
+func (e *HeaderError) Unwrap() error {
+	return ErrMalformedHeader
+}
+
+// sfToken is a Token bare item, kept apart from String bare items.
+type sfToken string
+
+// sfItem is an Item of a structured field: a bare item, which is a string,
+// sfToken, int64, float64, bool or []byte, followed by parameters.
+type sfItem struct {
+	value  any
+	params []sfParam
+}
+
+type sfParam struct {
+	key   string
+	value any
+}
+
+// param returns the value of the parameter key.
+func (it sfItem) param(key string) (any, bool) {
+	for _, p := range it.params {
+		if p.key == key {
+			return p.value, true
+		}
+	}
+	return nil, false
+}
+
+// sfMember is a member of a List. It is an Inner List if inner is not nil,
+// in which case the parameters belong to the inner list.
+type sfMember struct {
+	sfItem
+	inner []sfItem
+}
+
+// sfParser parses structured fields as told by section 4.2 of RFC 8941.
+type sfParser struct {
+	s string
+	i int
+}
+
+func (p *sfParser) error(reason string) error {
+	return &HeaderError{Value: p.s, Offset: p.i, Reason: reason}
+}
+
+// parseSFList parses s as a List.
+func parseSFList(s string) ([]sfMember, error) {
+	p := &sfParser{s: strings.Trim(s, " ")}
+	var members []sfMember
+	for p.i < len(p.s) {
+		member, err := p.member()
+		if err != nil {
+			return nil, err
+		}
+		members = append(members, member)
+		p.skipOWS()
+		if p.i == len(p.s) {
+			break
+		}
+		if p.s[p.i] != ',' {
+			return nil, p.error("expected comma")
+		}
+		p.i++
+		p.skipOWS()
+		if p.i == len(p.s) {
+			return nil, p.error("trailing comma")
+		}
+	}
+	return members, nil
+}
+
+// parseSFItem parses s as an Item.
+func parseSFItem(s string) (sfItem, error) {
+	p := &sfParser{s: strings.Trim(s, " ")}
+	item, err := p.item()
+	if err == nil && p.i < len(p.s) {
+		err = p.error("unexpected character")
+	}
+	return item, err
+}
+
+func (p *sfParser) skipOWS() {
+	for p.i < len(p.s) && (p.s[p.i] == ' ' || p.s[p.i] == '\t') {
+		p.i++
+	}
+}
+
+func (p *sfParser) skipSP() {
+	for p.i < len(p.s) && p.s[p.i] == ' ' {
+		p.i++
+	}
+}
+
+func (p *sfParser) member() (sfMember, error) {
+	if p.i < len(p.s) && p.s[p.i] == '(' {
+		return p.innerList()
+	}
+	item, err := p.item()
+	return sfMember{sfItem: item}, err
+}
+
+func (p *sfParser) innerList() (sfMember, error) {
+	p.i++ // (
+	member := sfMember{inner: []sfItem{}}
+	for {
+		p.skipSP()
+		if p.i == len(p.s) {
+			return member, p.error("unterminated inner list")
+		}
+		if p.s[p.i] == ')' {
+			p.i++
+			params, err := p.params()
+			member.params = params
+			return member, err
+		}
+		item, err := p.item()
+		if err != nil {
+			return member, err
+		}
+		member.inner = append(member.inner, item)
+		if p.i < len(p.s) && p.s[p.i] != ' ' && p.s[p.i] != ')' {
+			return member, p.error("expected space or closing parenthesis")
+		}
+	}
+}
+
+func (p *sfParser) item() (sfItem, error) {
+	value, err := p.bareItem()
+	if err != nil {
+		return sfItem{}, err
+	}
+	params, err := p.params()
+	return sfItem{value: value, params: params}, err
+}
+
+func (p *sfParser) params() ([]sfParam, error) {
+	var params []sfParam
+	for p.i < len(p.s) && p.s[p.i] == ';' {
+		p.i++
+		p.skipSP()
+		key, err := p.key()
+		if err != nil {
+			return nil, err
+		}
+		var value any = true
+		if p.i < len(p.s) && p.s[p.i] == '=' {
+			p.i++
+			if value, err = p.bareItem(); err != nil {
+				return nil, err
+			}
+		}
+		// a repeated key overwrites the earlier value
+		params = append(params, sfParam{key: key, value: value})
+		for i := range params[:len(params)-1] {
+			if params[i].key == key {
+				params[i].value = value
+				params = params[:len(params)-1]
+				break
+			}
+		}
+	}
+	return params, nil
+}
+
+func (p *sfParser) key() (string, error) {
+	start := p.i
+	if p.i == len(p.s) || !isLCAlpha(p.s[p.i]) && p.s[p.i] != '*' {
+		return "", p.error("expected key")
+	}
+	for p.i < len(p.s) && (isLCAlpha(p.s[p.i]) || isDigit(p.s[p.i]) || strings.IndexByte("_-.*", p.s[p.i]) >= 0) {
+		p.i++
+	}
+	return p.s[start:p.i], nil
+}
+
+func (p *sfParser) bareItem() (any, error) {
+	if p.i == len(p.s) {
+		return nil, p.error("expected item")
+	}
+	switch c := p.s[p.i]; {
+	case c == '-' || isDigit(c):
+		return p.number()
+	case c == '"':
+		return p.string()
+	case c == '*' || isAlpha(c):
+		return p.token(), nil
+	case c == ':':
+		return p.byteSequence()
+	case c == '?':
+		return p.boolean()
+	default:
+		return nil, p.error("unexpected character")
+	}
+}
+
+func (p *sfParser) number() (any, error) {
+	start := p.i
+	if p.s[p.i] == '-' {
+		p.i++
+	}
+	digits, dot := 0, -1
+	for ; p.i < len(p.s); p.i++ {
+		c := p.s[p.i]
+		if c == '.' && dot < 0 {
+			if digits > 12 {
+				return nil, p.error("decimal too long")
+			}
+			dot = p.i
+			continue
+		}
+		if !isDigit(c) {
+			break
+		}
+		digits++
+		if dot < 0 && digits > 15 || dot >= 0 && p.i-dot > 3 {
+			return nil, p.error("number too long")
+		}
+	}
+	num := p.s[start:p.i]
+	if digits == 0 || dot == p.i-1 {
+		return nil, p.error("malformed number")
+	}
+	if dot < 0 {
+		n, err := strconv.ParseInt(num, 10, 64)
+		if err != nil {
+			return nil, p.error("malformed integer")
+		}
+		return n, nil
+	}
+	f, err := strconv.ParseFloat(num, 64)
+	if err != nil {
+		return nil, p.error("malformed decimal")
+	}
+	return f, nil
+}
+
+func (p *sfParser) string() (string, error) {
+	p.i++ // "
+	var b strings.Builder
+	for p.i < len(p.s) {
+		c := p.s[p.i]
+		p.i++
+		switch {
+		case c == '\\':
+			if p.i == len(p.s) || p.s[p.i] != '"' && p.s[p.i] != '\\' {
+				return "", p.error("invalid escape")
+			}
+			b.WriteByte(p.s[p.i])
+			p.i++
+		case c == '"':
+			return b.String(), nil
+		case c < 0x20 || c > 0x7e:
+			p.i--
+			return "", p.error("invalid character in string")
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return "", p.error("unterminated string")
+}
+
+func (p *sfParser) token() sfToken {
+	start := p.i
+	p.i++
+	for p.i < len(p.s) && (isTChar(p.s[p.i]) || p.s[p.i] == ':' || p.s[p.i] == '/') {
+		p.i++
+	}
+	return sfToken(p.s[start:p.i])
+}
+
+func (p *sfParser) byteSequence() ([]byte, error) {
+	p.i++ // :
+	end := strings.IndexByte(p.s[p.i:], ':')
+	if end < 0 {
+		return nil, p.error("unterminated byte sequence")
+	}
+	b, err := base64.StdEncoding.DecodeString(p.s[p.i : p.i+end])
+	if err != nil {
+		return nil, p.error("malformed byte sequence")
+	}
+	p.i += end + 1
+	return b, nil
+}
+
+func (p *sfParser) boolean() (bool, error) {
+	p.i++ // ?
+	if p.i < len(p.s) && (p.s[p.i] == '0' || p.s[p.i] == '1') {
+		p.i++
+		return p.s[p.i-1] == '1', nil
+	}
+	return false, p.error("expected 0 or 1")
+}
+
+func isDigit(c byte) bool {
+	return '0' <= c && c <= '9'
+}
+
+func isLCAlpha(c byte) bool {
+	return 'a' <= c && c <= 'z'
+}
+
+func isAlpha(c byte) bool {
+	return isLCAlpha(c) || 'A' <= c && c <= 'Z'
+}
+
+// isTChar reports whether c is a tchar of RFC 9110.
+func isTChar(c byte) bool {
+	return isAlpha(c) || isDigit(c) || strings.IndexByte("!#$%&'*+-.^_`|~", c) >= 0
+}
+
+// splitSFList splits a list on the commas outside quoted strings.
+func splitSFList(value string) []string {
+	return splitSF(value, ',')
+}
+
+// splitSF splits value on the separators outside quoted strings. A quote
+// preceded by a backslash doesn't end a string.
+func splitSF(value string, sep byte) []string {
+	var parts []string
+	quoted, start := false, 0
+	for i := 0; i < len(value); i++ {
+		switch c := value[i]; {
+		case c == '\\' && quoted:
+			i++
+		case c == '"':
+			quoted = !quoted
+		case c == sep && !quoted:
+			parts = append(parts, value[start:i])
+			start = i + 1
+		}
+	}
+	return append(parts, value[start:])
+}
+
+// lenientString reads a string that isn't a valid structured field string,
+// dropping its quotes and the backslashes escaping them.
+func lenientString(value string) string {
+	return strings.TrimSpace(quoteReplaceReg.ReplaceAllString(value, ""))
+}
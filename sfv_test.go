@@ -0,0 +1,93 @@
+package uaparser
+
+import (
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestParseSFList(t *testing.T) {
+	list, err := parseSFList(`"a, \"b\"";v="1";q, tok/1.0;n=-12;d=3.5, ?0, :aGk=:, (1 "x");p, ()`)
+	require.NoError(t, err)
+	require.Len(t, list, 6)
+	assert.Equal(t, `a, "b"`, list[0].value)
+	assert.Equal(t, []sfParam{{"v", "1"}, {"q", true}}, list[0].params)
+	assert.Equal(t, sfToken("tok/1.0"), list[1].value)
+	assert.Equal(t, []sfParam{{"n", int64(-12)}, {"d", 3.5}}, list[1].params)
+	assert.Equal(t, false, list[2].value)
+	assert.Equal(t, []byte("hi"), list[3].value)
+	assert.Equal(t, []sfItem{{value: int64(1)}, {value: "x"}}, list[4].inner)
+	assert.Equal(t, []sfParam{{"p", true}}, list[4].params)
+	assert.Equal(t, []sfItem{}, list[5].inner)
+
+	list, err = parseSFList("")
+	require.NoError(t, err)
+	assert.Empty(t, list)
+
+	for _, s := range []string{
+		`"a",`, `"a" "b"`, `"unterminated`, `"bad\escape"`, `?2`, `1.`, `1.2345`,
+		`1234567890123456`, `:not base64:`, `(1 2`, `"a";V=1`, `"a";v=`, `@`, "\"tab\tin\"",
+	} {
+		_, err := parseSFList(s)
+		assert.ErrorIs(t, err, ErrMalformedHeader, s)
+	}
+
+	item, err := parseSFItem(`"Windows"`)
+	require.NoError(t, err)
+	assert.Equal(t, "Windows", item.value)
+	_, err = parseSFItem(`"Windows" x`)
+	assert.Error(t, err)
+}
+
+func TestParseClientHints(t *testing.T) {
+	ch, err := ParseClientHints(map[string]string{
+		CHHeader:            `" Not A;Brand";v="99", "Chromium";v="120";x=1, "Brand, \"Quoted\"";v="1"`,
+		CHHeaderMobile:      "?1",
+		CHHeaderPlatform:    `"Android"`,
+		CHHeaderFormFactors: `"Mobile", "EInk"`,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []IBrand{
+		{Name: " Not A;Brand", Version: "99"},
+		{Name: "Chromium", Version: "120"},
+		{Name: `Brand, "Quoted"`, Version: "1"},
+	}, ch.Brands)
+	assert.True(t, ch.Mobile)
+	assert.Equal(t, "Android", ch.Platform)
+	assert.Equal(t, []string{"Mobile", "EInk"}, ch.FormFactors)
+
+	// malformed headers are reported and read leniently
+	ch, err = ParseClientHints(map[string]string{
+		CHHeader:      `"Chromium";x, "Google Chrome";v="120`,
+		CHHeaderModel: "Pixel 99",
+	})
+	assert.ErrorIs(t, err, ErrMalformedHeader)
+	var he *HeaderError
+	require.True(t, errors.As(err, &he))
+	assert.Contains(t, []string{CHHeader, CHHeaderModel}, he.Header)
+	assert.Equal(t, []IBrand{{Name: "Chromium"}, {Name: "Google Chrome", Version: "120"}}, ch.Brands)
+	assert.Equal(t, "Pixel 99", ch.Model)
+}
+
+func FuzzParseClientHints(f *testing.F) {
+	f.Add(`" Not A;Brand";v="99", "Chromium";v="120"`, "?1", `"Pixel 7"`)
+	f.Add(`"Chromium;v=120, Not_A Brand;v=8"`, "?0", "Pixel 99")
+	f.Add(`"a";b;c=?1, (1 2);d, :aGk=:`, "?2", `"\"`)
+	f.Add(`"x";`, "", `"`)
+	f.Fuzz(func(t *testing.T, brands, mobile, model string) {
+		headers := map[string]string{
+			CHHeader:            brands,
+			CHHeaderFullVerList: brands,
+			CHHeaderFormFactors: brands,
+			CHHeaderMobile:      mobile,
+			CHHeaderModel:       model,
+		}
+		ch, err := ParseClientHints(headers)
+		if err != nil {
+			assert.ErrorIs(t, err, ErrMalformedHeader)
+		}
+		assert.Equal(t, ch, NewClientHints(headers))
+		NewUAParser("").WithHeaders(headers).Result()
+	})
+}
@@ -0,0 +1,71 @@
+package uaparser
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// UAData mirrors the NavigatorUAData of browsers, i.e. navigator.userAgentData
+// or the result of its getHighEntropyValues method, as it reads once encoded
+// with JSON.stringify.
+type UAData struct {
+	Brands          []UADataBrand `json:"brands,omitempty"`
+	FullVersionList []UADataBrand `json:"fullVersionList,omitempty"`
+	Mobile          bool          `json:"mobile,omitempty"`
+	Model           string        `json:"model,omitempty"`
+	Platform        string        `json:"platform,omitempty"`
+	PlatformVersion string        `json:"platformVersion,omitempty"`
+	Architecture    string        `json:"architecture,omitempty"`
+	Bitness         string        `json:"bitness,omitempty"`
+	FormFactors     []string      `json:"formFactors,omitempty"`
+	Wow64           bool          `json:"wow64,omitempty"`
+	// UAFullVersion is deprecated in favor of FullVersionList.
+	UAFullVersion string `json:"uaFullVersion,omitempty"`
+}
+
+// UADataBrand is a brand of UAData.
+type UADataBrand struct {
+	Brand   string `json:"brand"`
+	Version string `json:"version"`
+}
+
+// ClientHintsFromUAData returns the client hints of data.
+func ClientHintsFromUAData(data UAData) ClientHints {
+	brands := func(list []UADataBrand) []IBrand {
+		if len(list) == 0 {
+			return nil
+		}
+		arr := make([]IBrand, len(list))
+		for i, brand := range list {
+			arr[i] = IBrand{Name: brand.Brand, Version: brand.Version}
+		}
+		return arr
+	}
+	return ClientHints{
+		Brands:          brands(data.Brands),
+		FullVersionList: brands(data.FullVersionList),
+		Mobile:          data.Mobile,
+		Model:           data.Model,
+		Platform:        data.Platform,
+		PlatformVersion: data.PlatformVersion,
+		FormFactors:     data.FormFactors,
+		Architecture:    data.Architecture,
+		Bitness:         data.Bitness,
+		Wow64:           data.Wow64,
+		FullVersion:     data.UAFullVersion,
+	}
+}
+
+// ClientHintsFromJSON reads client hints sent by a frontend as the JSON
+// encoding of UAData, e.g.
+//
+//	const data = await navigator.userAgentData.getHighEntropyValues(
+//		["architecture", "bitness", "fullVersionList", "model", "platformVersion"])
+//	fetch("/hints", {method: "POST", body: JSON.stringify(data)})
+func ClientHintsFromJSON(data []byte) (ClientHints, error) {
+	var uaData UAData
+	if err := json.Unmarshal(data, &uaData); err != nil {
+		return ClientHints{}, fmt.Errorf("uaparser: client hints: %w", err)
+	}
+	return ClientHintsFromUAData(uaData), nil
+}
@@ -0,0 +1,52 @@
+package uaparser
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestClientHintsFromJSON(t *testing.T) {
+	ch, err := ClientHintsFromJSON([]byte(`{
+		"architecture": "x86",
+		"bitness": "64",
+		"brands": [
+			{"brand": "Not_A Brand", "version": "8"},
+			{"brand": "Chromium", "version": "120"},
+			{"brand": "Google Chrome", "version": "120"}
+		],
+		"fullVersionList": [
+			{"brand": "Not_A Brand", "version": "8.0.0.0"},
+			{"brand": "Chromium", "version": "120.0.6099.130"},
+			{"brand": "Google Chrome", "version": "120.0.6099.130"}
+		],
+		"mobile": false,
+		"model": "",
+		"platform": "Windows",
+		"platformVersion": "15.0.0",
+		"wow64": false
+	}`))
+	require.NoError(t, err)
+	assert.Equal(t, IBrand{Name: "Google Chrome", Version: "120.0.6099.130"}, ch.FullVersionList[2])
+	assert.Equal(t, "15.0.0", ch.PlatformVersion)
+
+	headers := map[string]string{
+		CHHeader:            `"Not_A Brand";v="8", "Chromium";v="120", "Google Chrome";v="120"`,
+		CHHeaderFullVerList: `"Not_A Brand";v="8.0.0.0", "Chromium";v="120.0.6099.130", "Google Chrome";v="120.0.6099.130"`,
+		CHHeaderArch:        `"x86"`,
+		CHHeaderBitness:     `"64"`,
+		CHHeaderMobile:      "?0",
+		CHHeaderModel:       `""`,
+		CHHeaderPlatform:    `"Windows"`,
+		CHHeaderPlatformVer: `"15.0.0"`,
+	}
+	assert.Equal(t, NewClientHints(headers), ch)
+
+	result := NewUAParser(cacheChromeUA).WithClientHints(ch).Result()
+	assert.Equal(t, NewUAParser(cacheChromeUA).WithHeaders(headers).Result(), result)
+	assert.Equal(t, IOs{Platform: "Windows", Name: "Windows", Version: "11"}, result.Os)
+	assert.Equal(t, "120.0.6099.130", result.Browser.Version)
+
+	_, err = ClientHintsFromJSON([]byte(`{"brands": "Chromium"}`))
+	assert.Error(t, err)
+}
@@ -0,0 +1,153 @@
+package uaparser
+
+import (
+	"errors"
+	"fmt"
+	"gopkg.in/yaml.v3"
+	"io"
+	"os"
+	"strings"
+)
+
+// uapCoreFile mirrors the layout of regexes.yaml from ua-parser/uap-core.
+type uapCoreFile struct {
+	UserAgentParsers []uapCoreParser `yaml:"user_agent_parsers"`
+	OsParsers        []uapCoreParser `yaml:"os_parsers"`
+	DeviceParsers    []uapCoreParser `yaml:"device_parsers"`
+}
+
+type uapCoreParser struct {
+	Regex     string `yaml:"regex"`
+	RegexFlag string `yaml:"regex_flag"`
+
+	FamilyReplacement string `yaml:"family_replacement"`
+	V1Replacement     string `yaml:"v1_replacement"`
+	V2Replacement     string `yaml:"v2_replacement"`
+	V3Replacement     string `yaml:"v3_replacement"`
+	V4Replacement     string `yaml:"v4_replacement"`
+
+	OSReplacement   string `yaml:"os_replacement"`
+	OSV1Replacement string `yaml:"os_v1_replacement"`
+	OSV2Replacement string `yaml:"os_v2_replacement"`
+	OSV3Replacement string `yaml:"os_v3_replacement"`
+	OSV4Replacement string `yaml:"os_v4_replacement"`
+
+	DeviceReplacement string `yaml:"device_replacement"`
+	BrandReplacement  string `yaml:"brand_replacement"`
+	ModelReplacement  string `yaml:"model_replacement"`
+}
+
+// LoadUAPCore imports a regexes.yaml file of ua-parser/uap-core:
+//
+//   - user_agent_parsers become browser rules: the family is the name and
+//     v1-v4 are joined into the version.
+//   - os_parsers become os rules in the same way.
+//   - device_parsers become device rules: brand_replacement is the vendor and
+//     model_replacement the model, $1 by default as in uap-core.
+//     device_replacement (the uap-core family) has no counterpart in IDevice
+//     and is ignored.
+//
+// Capture groups follow the uap-core conventions: without a replacement the
+// family is $1 and the version parts are $2 to $5. Replacements may contain
+// $n references. uap-core has no cpu or engine rules, so the embedded ones
+// are used for those components.
+func LoadUAPCore(r io.Reader) (*Rules, error) {
+	var file uapCoreFile
+	if err := yaml.NewDecoder(r).Decode(&file); err != nil && !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("uaparser: decode uap-core regexes: %w", err)
+	}
+
+	items := map[string][]regexItem{
+		UACpu:    defaultRules.items[UACpu],
+		UAEngine: defaultRules.items[UAEngine],
+	}
+	var errs []error
+	add := func(component, section string, i int, b *RuleBuilder) {
+		rule, err := b.Build()
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s[%d]: %w", section, i, err))
+			return
+		}
+		items[component] = append(items[component], rule.item)
+	}
+
+	for i, p := range file.UserAgentParsers {
+		b, groups := p.rule()
+		b.Output(Name, uapReplacement(p.FamilyReplacement, 1, groups))
+		b.Output(Version, uapVersion(groups, 2, p.V1Replacement, p.V2Replacement, p.V3Replacement, p.V4Replacement))
+		add(UABrowser, "user_agent_parsers", i, b.Mapper(Name, strings.TrimSpace).Mapper(Version, joinVersion))
+	}
+	for i, p := range file.OsParsers {
+		b, groups := p.rule()
+		b.Output(Name, uapReplacement(p.OSReplacement, 1, groups))
+		b.Output(Version, uapVersion(groups, 2, p.OSV1Replacement, p.OSV2Replacement, p.OSV3Replacement, p.OSV4Replacement))
+		add(UAOS, "os_parsers", i, b.Mapper(Name, strings.TrimSpace).Mapper(Version, joinVersion))
+	}
+	for i, p := range file.DeviceParsers {
+		b, groups := p.rule()
+		b.Output(Model, uapReplacement(p.ModelReplacement, 1, groups))
+		if p.BrandReplacement != "" {
+			b.Output(Vendor, p.BrandReplacement)
+		}
+		add(UADevice, "device_parsers", i, b.Mapper(Model, strings.TrimSpace))
+	}
+
+	if err := errors.Join(errs...); err != nil {
+		return nil, err
+	}
+	return newRules(items), nil
+}
+
+// LoadUAPCoreFile imports a uap-core regexes.yaml file.
+func LoadUAPCoreFile(path string) (*Rules, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer func(f *os.File) {
+		_ = f.Close()
+	}(f)
+	return LoadUAPCore(f)
+}
+
+// rule starts a rule for the parser's regex and reports how many groups it
+// has, so the default replacements only reference existing groups.
+func (p uapCoreParser) rule() (*RuleBuilder, int) {
+	pattern := p.Regex
+	if strings.Contains(p.RegexFlag, "i") {
+		pattern = "(?i)" + pattern
+	}
+	groups, _ := countGroups(pattern)
+	return NewRule(pattern), groups
+}
+
+func uapReplacement(replacement string, group, groups int) string {
+	if replacement != "" {
+		return replacement
+	}
+	if group > groups {
+		return ""
+	}
+	return fmt.Sprintf("$%d", group)
+}
+
+func uapVersion(groups, first int, replacements ...string) string {
+	parts := make([]string, len(replacements))
+	for i, replacement := range replacements {
+		parts[i] = uapReplacement(replacement, first+i, groups)
+	}
+	return strings.Join(parts, ".")
+}
+
+// joinVersion drops the version parts following the first empty one, as
+// uap-core does: "10.15..", "10.15..7" and "10.15" all become "10.15".
+func joinVersion(version string) string {
+	parts := strings.Split(version, ".")
+	for i, part := range parts {
+		if strings.TrimSpace(part) == "" {
+			parts = parts[:i]
+			break
+		}
+	}
+	return strings.Join(parts, ".")
+}
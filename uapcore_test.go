@@ -0,0 +1,99 @@
+package uaparser
+
+import (
+	"github.com/stretchr/testify/assert"
+	"strings"
+	"testing"
+)
+
+func TestLoadUAPCoreFile(t *testing.T) {
+	rules, err := LoadUAPCoreFile("./data/uap-core/regexes-sample.yaml")
+	assert.NoError(t, err)
+	assert.Equal(t, 7, rules.Len(UABrowser))
+	assert.Equal(t, 4, rules.Len(UAOS))
+	assert.Equal(t, 5, rules.Len(UADevice))
+	assert.Equal(t, DefaultRules().Len(UAEngine), rules.Len(UAEngine))
+
+	testCases := []struct {
+		ua     string
+		expect IResult
+	}{
+		{
+			ua: "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/132.0.0.0 Safari/537.36 Edg/132.0.2957.140",
+			expect: IResult{
+				Browser:    IBrowser{Name: "Edge", Version: "132.0.2957.140", Major: "132"},
+				Cpu:        ICpu{Architecture: "amd64", Bitness: "64"},
+				Engine:     IEngine{Name: "Blink", Version: "132.0.0.0"},
+				Os:         IOs{Platform: "Windows", Name: "Windows", Version: "10"},
+				Reduced:    true,
+				Unreliable: []string{FieldEngineVersion, FieldOSVersion, FieldCPUArchitecture},
+			},
+		},
+		{
+			ua: "Mozilla/5.0 (Linux; Android 14; SM-S918B) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/121.0.6167.143 Mobile Safari/537.36",
+			expect: IResult{
+				Browser: IBrowser{Name: "Chrome", Version: "121.0.6167.143", Major: "121"},
+				Device:  IDevice{Model: "SM-S918B", Vendor: "Samsung"},
+				Engine:  IEngine{Name: "Blink", Version: "121.0.6167.143"},
+				Os:      IOs{Platform: "Android", Name: "Android", Version: "14"},
+			},
+		},
+		{
+			ua: "Mozilla/5.0 (iPhone; CPU iPhone OS 17_2_1 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/17.2 Mobile/15E148 Safari/604.1",
+			expect: IResult{
+				Browser: IBrowser{Name: "Mobile Safari", Version: "17.2", Major: "17"},
+				Device:  IDevice{Model: "iPhone", Vendor: "Apple"},
+				Engine:  IEngine{Name: "WebKit", Version: "605.1.15"},
+				Os:      IOs{Platform: "iOS", Name: "iOS", Version: "17.2.1"},
+			},
+		},
+		{
+			ua: "Mozilla/5.0 (Linux; Android 13; Pixel 7) AppleWebKit/537.36 (KHTML, like Gecko) HeadlessChrome/120.0.6099.5 Safari/537.36",
+			expect: IResult{
+				Browser: IBrowser{Name: "HeadlessChrome", Version: "120.0.6099", Major: "120"},
+				Device:  IDevice{Model: "Pixel 7", Vendor: "Google"},
+				Engine:  IEngine{Name: "Blink", Version: "120.0.6099.5"},
+				Os:      IOs{Platform: "Android", Name: "Android", Version: "13"},
+				Automation: IAutomation{
+					Framework: AutomationHeadlessChrome, Confidence: 0.95, Signals: []string{SignalUAToken},
+				},
+			},
+		},
+		{
+			ua: "Mozilla/5.0 (compatible; Googlebot/2.1; +http://www.google.com/bot.html)",
+			expect: IResult{
+				Browser: IBrowser{Name: "Googlebot", Version: "2.1", Major: "2"},
+				Device:  IDevice{Model: "Desktop", Vendor: "Spider"},
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		r := NewUAParser(tc.ua).WithRules(rules).Result()
+		tc.expect.UA = tc.ua
+		assert.Equal(t, tc.expect, r, tc.ua)
+	}
+
+	// without model_replacement the model is $1, whatever device_replacement
+	// says
+	device := NewUAParser("Mozilla/5.0 (Windows Phone 10.0; Android 6.0.1; Microsoft; Lumia 950) AppleWebKit/537.36").WithRules(rules).Device()
+	assert.Equal(t, IDevice{Model: "Lumia 950", Vendor: "Nokia"}, device)
+}
+
+func TestLoadUAPCore_Errors(t *testing.T) {
+	_, err := LoadUAPCore(strings.NewReader("user_agent_parsers:\n  - regex: '(Foo'\n"))
+	assert.ErrorContains(t, err, "user_agent_parsers[0]")
+
+	_, err = LoadUAPCore(strings.NewReader("device_parsers:\n  - regex: 'Foo'\n    model_replacement: '$2'\n"))
+	assert.ErrorContains(t, err, "device_parsers[0]")
+
+	_, err = LoadUAPCore(strings.NewReader("user_agent_parsers: 1\n"))
+	assert.ErrorContains(t, err, "decode uap-core")
+}
+
+func TestJoinVersion(t *testing.T) {
+	assert.Equal(t, "10.15", joinVersion("10.15.."))
+	assert.Equal(t, "10", joinVersion("10..3."))
+	assert.Equal(t, "", joinVersion("..."))
+	assert.Equal(t, "1.2.3.4", joinVersion("1.2.3.4"))
+}
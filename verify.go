@@ -0,0 +1,346 @@
+package uaparser
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/netip"
+	"os"
+	"strings"
+	"time"
+)
+
+// ErrNoCrawlerRule is returned by CrawlerVerifier.Verify for crawlers it has
+// no way to verify.
+var ErrNoCrawlerRule = errors.New("uaparser: no verification rule for crawler")
+
+// ErrInvalidIP is returned by CrawlerVerifier.Verify for the zero netip.Addr.
+var ErrInvalidIP = errors.New("uaparser: invalid IP")
+
+// Resolver looks up the DNS records used to verify crawlers. *net.Resolver
+// implements it.
+type Resolver interface {
+	LookupAddr(ctx context.Context, addr string) ([]string, error)
+	LookupIPAddr(ctx context.Context, host string) ([]net.IPAddr, error)
+}
+
+// CrawlerRule tells how to verify the crawler called Name, as named by
+// BotInfo.Name. A client is verified when its IP is in one of Ranges, or
+// when its reverse DNS name is in one of the Domains and resolves back to
+// the IP.
+type CrawlerRule struct {
+	Name    string
+	Domains []string
+	Ranges  []netip.Prefix
+}
+
+// VerifyMethod is how a crawler was verified.
+type VerifyMethod string
+
+const (
+	VerifiedByDNS     VerifyMethod = "dns"
+	VerifiedByIPRange VerifyMethod = "ip-range"
+)
+
+// Verification is the outcome of CrawlerVerifier.Verify.
+type Verification struct {
+	Crawler  string       `json:"crawler"`
+	IP       netip.Addr   `json:"ip"`
+	Verified bool         `json:"verified"`
+	Method   VerifyMethod `json:"method,omitempty"`
+	// Host is the reverse DNS name confirmed by the forward lookup.
+	Host string `json:"host,omitempty"`
+}
+
+// CrawlerVerifier confirms that a client claiming to be a crawler comes
+// from its operator. Verifications are cached, failed lookups excepted. It
+// is safe for concurrent use.
+type CrawlerVerifier struct {
+	resolver Resolver
+	rules    map[string]CrawlerRule
+	cache    *lru[verifyKey, Verification]
+}
+
+type verifyKey struct {
+	crawler string
+	ip      netip.Addr
+}
+
+// VerifierOption configures a CrawlerVerifier.
+type VerifierOption func(*CrawlerVerifier)
+
+// VerifierResolver sets the resolver, net.DefaultResolver by default.
+func VerifierResolver(resolver Resolver) VerifierOption {
+	return func(v *CrawlerVerifier) {
+		v.resolver = resolver
+	}
+}
+
+// VerifierCache sets the number of verifications kept and for how long,
+// 4096 for a day by default.
+func VerifierCache(size int, ttl time.Duration) VerifierOption {
+	return func(v *CrawlerVerifier) {
+		v.cache = newLRU[verifyKey, Verification](size, ttl)
+	}
+}
+
+// VerifierRules adds rules, replacing the rules of crawlers with the same
+// name.
+func VerifierRules(rules ...CrawlerRule) VerifierOption {
+	return func(v *CrawlerVerifier) {
+		for _, rule := range rules {
+			v.rules[strings.ToLower(rule.Name)] = rule
+		}
+	}
+}
+
+// VerifierIPRanges adds ranges to the rule of crawler, creating it if
+// needed. The ranges usually come from LoadIPRanges.
+func VerifierIPRanges(crawler string, ranges ...netip.Prefix) VerifierOption {
+	return func(v *CrawlerVerifier) {
+		key := strings.ToLower(crawler)
+		rule, ok := v.rules[key]
+		if !ok {
+			rule.Name = crawler
+		}
+		rule.Ranges = append(rule.Ranges[:len(rule.Ranges):len(rule.Ranges)], ranges...)
+		v.rules[key] = rule
+	}
+}
+
+// NewCrawlerVerifier returns a verifier using DefaultCrawlerRules and the
+// given options.
+func NewCrawlerVerifier(opts ...VerifierOption) *CrawlerVerifier {
+	v := &CrawlerVerifier{
+		resolver: net.DefaultResolver,
+		rules:    make(map[string]CrawlerRule, len(DefaultCrawlerRules)),
+		cache:    newLRU[verifyKey, Verification](4096, 24*time.Hour),
+	}
+	for _, rule := range DefaultCrawlerRules {
+		v.rules[strings.ToLower(rule.Name)] = rule
+	}
+	for _, opt := range opts {
+		opt(v)
+	}
+	return v
+}
+
+// CanVerify reports whether v has a rule for crawler.
+func (v *CrawlerVerifier) CanVerify(crawler string) bool {
+	_, ok := v.rules[strings.ToLower(crawler)]
+	return ok
+}
+
+// Verify checks that ip belongs to crawler, the name found by DetectBot. It
+// returns ErrInvalidIP for an invalid ip, ErrNoCrawlerRule for unknown
+// crawlers, and the resolver error when a lookup fails for other reasons
+// than a missing record.
+func (v *CrawlerVerifier) Verify(ctx context.Context, crawler string, ip netip.Addr) (Verification, error) {
+	if !ip.IsValid() {
+		return Verification{}, ErrInvalidIP
+	}
+	rule, ok := v.rules[strings.ToLower(crawler)]
+	if !ok {
+		return Verification{}, fmt.Errorf("%w: %q", ErrNoCrawlerRule, crawler)
+	}
+	ip = ip.Unmap()
+	key := verifyKey{crawler: strings.ToLower(crawler), ip: ip}
+	if result, ok := v.cache.get(key); ok {
+		return result, nil
+	}
+
+	result := Verification{Crawler: rule.Name, IP: ip}
+	for _, prefix := range rule.Ranges {
+		if prefix.Contains(ip) {
+			result.Verified, result.Method = true, VerifiedByIPRange
+			v.cache.put(key, result)
+			return result, nil
+		}
+	}
+	if len(rule.Domains) > 0 {
+		host, err := v.lookup(ctx, rule.Domains, ip)
+		if err != nil {
+			return result, err
+		}
+		if host != "" {
+			result.Verified, result.Method, result.Host = true, VerifiedByDNS, host
+		}
+	}
+	v.cache.put(key, result)
+	return result, nil
+}
+
+// VerifyBot is Verify for a crawler found by DetectBot.
+func (v *CrawlerVerifier) VerifyBot(ctx context.Context, bot BotInfo, ip netip.Addr) (Verification, error) {
+	return v.Verify(ctx, bot.Name, ip)
+}
+
+// CacheStats returns the counters of the verification cache.
+func (v *CrawlerVerifier) CacheStats() CacheStats {
+	return v.cache.stats()
+}
+
+// lookup returns the reverse DNS name of ip under one of domains which
+// resolves back to ip, or "" if there is none.
+func (v *CrawlerVerifier) lookup(ctx context.Context, domains []string, ip netip.Addr) (string, error) {
+	names, err := v.resolver.LookupAddr(ctx, ip.String())
+	if err != nil {
+		return "", ignoreNotFound(err)
+	}
+	for _, name := range names {
+		host := strings.ToLower(strings.TrimSuffix(name, "."))
+		if !inDomains(host, domains) {
+			continue
+		}
+		addrs, err := v.resolver.LookupIPAddr(ctx, host)
+		if err != nil {
+			if err = ignoreNotFound(err); err != nil {
+				return "", err
+			}
+			continue
+		}
+		for _, addr := range addrs {
+			if a, ok := netip.AddrFromSlice(addr.IP); ok && a.Unmap() == ip {
+				return host, nil
+			}
+		}
+	}
+	return "", nil
+}
+
+// inDomains reports whether host is strictly under one of domains, so that
+// googlebot.com.example.net does not pass for googlebot.com.
+func inDomains(host string, domains []string) bool {
+	for _, domain := range domains {
+		if strings.HasSuffix(host, "."+domain) {
+			return true
+		}
+	}
+	return false
+}
+
+func ignoreNotFound(err error) error {
+	var dnsErr *net.DNSError
+	if errors.As(err, &dnsErr) && dnsErr.IsNotFound {
+		return nil
+	}
+	return err
+}
+
+// LoadIPRanges reads the IP ranges published by crawler operators, either
+// as JSON with a "prefixes" list of "ipv4Prefix" or "ipv6Prefix" members, as
+// Google, Bing and OpenAI publish them, or as one CIDR prefix or address per
+// line, "#" starting a comment.
+func LoadIPRanges(r io.Reader) ([]netip.Prefix, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	if trimmed := bytes.TrimSpace(data); len(trimmed) > 0 && trimmed[0] == '{' {
+		return parseJSONRanges(trimmed)
+	}
+
+	var prefixes []netip.Prefix
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for n := 1; scanner.Scan(); n++ {
+		line, _, _ := strings.Cut(scanner.Text(), "#")
+		if line = strings.TrimSpace(line); line == "" {
+			continue
+		}
+		prefix, err := parsePrefix(line)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", n, err)
+		}
+		prefixes = append(prefixes, prefix)
+	}
+	return prefixes, scanner.Err()
+}
+
+// LoadIPRangesFile is LoadIPRanges reading the file at path.
+func LoadIPRangesFile(path string) ([]netip.Prefix, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer func(f *os.File) { _ = f.Close() }(f)
+	prefixes, err := LoadIPRanges(f)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return prefixes, nil
+}
+
+func parseJSONRanges(data []byte) ([]netip.Prefix, error) {
+	var doc struct {
+		Prefixes []struct {
+			IPv4 string `json:"ipv4Prefix"`
+			IPv6 string `json:"ipv6Prefix"`
+		} `json:"prefixes"`
+	}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+	prefixes := make([]netip.Prefix, 0, len(doc.Prefixes))
+	for _, p := range doc.Prefixes {
+		s := p.IPv4
+		if s == "" {
+			s = p.IPv6
+		}
+		prefix, err := parsePrefix(s)
+		if err != nil {
+			return nil, err
+		}
+		prefixes = append(prefixes, prefix)
+	}
+	return prefixes, nil
+}
+
+func parsePrefix(s string) (netip.Prefix, error) {
+	if !strings.Contains(s, "/") {
+		addr, err := netip.ParseAddr(s)
+		if err != nil {
+			return netip.Prefix{}, err
+		}
+		return netip.PrefixFrom(addr, addr.BitLen()), nil
+	}
+	prefix, err := netip.ParsePrefix(s)
+	if err != nil {
+		return netip.Prefix{}, err
+	}
+	return prefix.Masked(), nil
+}
+
+var (
+	googleDomains = []string{"googlebot.com", "google.com"}
+	yandexDomains = []string{"yandex.ru", "yandex.net", "yandex.com"}
+)
+
+// DefaultCrawlerRules are the reverse DNS domains documented by crawler
+// operators. Operators such as DuckDuckGo and OpenAI only publish IP
+// ranges, which are added with VerifierIPRanges.
+var DefaultCrawlerRules = []CrawlerRule{
+	{Name: "Googlebot", Domains: googleDomains},
+	{Name: "Storebot-Google", Domains: googleDomains},
+	{Name: "AdsBot-Google", Domains: googleDomains},
+	{Name: "Mediapartners-Google", Domains: googleDomains},
+	{Name: "Google-InspectionTool", Domains: googleDomains},
+	{Name: "GoogleOther", Domains: googleDomains},
+	{Name: "Google-CloudVertexBot", Domains: googleDomains},
+	{Name: "Bingbot", Domains: []string{"search.msn.com"}},
+	{Name: "BingPreview", Domains: []string{"search.msn.com"}},
+	{Name: "Applebot", Domains: []string{"applebot.apple.com"}},
+	{Name: "YandexBot", Domains: yandexDomains},
+	{Name: "YandexImages", Domains: yandexDomains},
+	{Name: "YandexMobileBot", Domains: yandexDomains},
+	{Name: "Baiduspider", Domains: []string{"baidu.com", "baidu.jp"}},
+	{Name: "Yeti", Domains: []string{"naver.com"}},
+	{Name: "SeznamBot", Domains: []string{"seznam.cz"}},
+	{Name: "PetalBot", Domains: []string{"petalsearch.com"}},
+	{Name: "Amazonbot", Domains: []string{"crawl.amazonbot.amazon"}},
+	{Name: "Yahoo! Slurp", Domains: []string{"crawl.yahoo.net"}},
+}
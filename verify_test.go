@@ -0,0 +1,174 @@
+package uaparser
+
+import (
+	"context"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"net"
+	"net/netip"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// fakeResolver answers from fixed records and counts the lookups.
+type fakeResolver struct {
+	ptr     map[string][]string
+	hosts   map[string][]string
+	lookups int
+}
+
+func (r *fakeResolver) LookupAddr(_ context.Context, addr string) ([]string, error) {
+	r.lookups++
+	if addr == "192.0.2.99" {
+		return nil, errors.New("server misbehaving")
+	}
+	names, ok := r.ptr[addr]
+	if !ok {
+		return nil, &net.DNSError{Err: "no such host", Name: addr, IsNotFound: true}
+	}
+	return names, nil
+}
+
+func (r *fakeResolver) LookupIPAddr(_ context.Context, host string) ([]net.IPAddr, error) {
+	r.lookups++
+	addrs, ok := r.hosts[host]
+	if !ok {
+		return nil, &net.DNSError{Err: "no such host", Name: host, IsNotFound: true}
+	}
+	var ips []net.IPAddr
+	for _, addr := range addrs {
+		ips = append(ips, net.IPAddr{IP: net.ParseIP(addr)})
+	}
+	return ips, nil
+}
+
+func TestCrawlerVerifier_DNS(t *testing.T) {
+	resolver := &fakeResolver{
+		ptr: map[string][]string{
+			"66.249.66.1":  {"crawl-66-249-66-1.googlebot.com."},
+			"203.0.113.5":  {"crawl-66-249-66-1.googlebot.com."},               // forward lookup disagrees
+			"203.0.113.6":  {"googlebot.com.example.net."},                     // not under googlebot.com
+			"157.55.39.1":  {"msnbot-157-55-39-1.search.msn.com."},             // Bing
+			"2001:db8::1":  {"crawl-2001-db8--1.googlebot.com."},               // IPv6
+			"198.51.100.7": {"nowhere.example.", "Crawl-7.GOOGLEBOT.com."},     // several names
+			"198.51.100.8": {"crawl-8.googlebot.com.", "crawl-8b.google.com."}, // first name dangling
+		},
+		hosts: map[string][]string{
+			"crawl-66-249-66-1.googlebot.com":   {"66.249.66.1"},
+			"msnbot-157-55-39-1.search.msn.com": {"157.55.39.1"},
+			"crawl-2001-db8--1.googlebot.com":   {"2001:db8::1"},
+			"crawl-7.googlebot.com":             {"198.51.100.7"},
+			"crawl-8b.google.com":               {"198.51.100.8"},
+			"googlebot.com.example.net":         {"203.0.113.6"},
+		},
+	}
+	v := NewCrawlerVerifier(VerifierResolver(resolver))
+	tests := []struct {
+		crawler string
+		ip      string
+		host    string
+	}{
+		{"Googlebot", "66.249.66.1", "crawl-66-249-66-1.googlebot.com"},
+		{"googlebot", "::ffff:66.249.66.1", "crawl-66-249-66-1.googlebot.com"},
+		{"Googlebot", "203.0.113.5", ""},
+		{"Googlebot", "203.0.113.6", ""},
+		{"Googlebot", "192.0.2.1", ""},
+		{"Googlebot", "2001:db8::1", "crawl-2001-db8--1.googlebot.com"},
+		{"Googlebot", "198.51.100.7", "crawl-7.googlebot.com"},
+		{"Googlebot", "198.51.100.8", "crawl-8b.google.com"},
+		{"Googlebot", "157.55.39.1", ""},
+		{"Bingbot", "157.55.39.1", "msnbot-157-55-39-1.search.msn.com"},
+	}
+	for _, tc := range tests {
+		result, err := v.Verify(context.Background(), tc.crawler, netip.MustParseAddr(tc.ip))
+		require.NoError(t, err, tc.ip)
+		assert.Equal(t, tc.host != "", result.Verified, tc.ip)
+		assert.Equal(t, tc.host, result.Host, tc.ip)
+		if result.Verified {
+			assert.Equal(t, VerifiedByDNS, result.Method)
+		}
+	}
+
+	_, err := v.Verify(context.Background(), "Googlebot", netip.MustParseAddr("192.0.2.99"))
+	assert.ErrorContains(t, err, "server misbehaving")
+	_, err = v.VerifyBot(context.Background(), DetectBot("curl/8.4.0"), netip.MustParseAddr("66.249.66.1"))
+	assert.ErrorIs(t, err, ErrNoCrawlerRule)
+	assert.False(t, v.CanVerify("curl"))
+	_, err = v.Verify(context.Background(), "Googlebot", netip.Addr{})
+	assert.ErrorIs(t, err, ErrInvalidIP)
+}
+
+func TestCrawlerVerifier_Cache(t *testing.T) {
+	resolver := &fakeResolver{
+		ptr:   map[string][]string{"66.249.66.1": {"crawl-66-249-66-1.googlebot.com."}},
+		hosts: map[string][]string{"crawl-66-249-66-1.googlebot.com": {"66.249.66.1"}},
+	}
+	v := NewCrawlerVerifier(VerifierResolver(resolver))
+	ip := netip.MustParseAddr("66.249.66.1")
+	bot := DetectBot("Mozilla/5.0 (compatible; Googlebot/2.1; +http://www.google.com/bot.html)")
+	for range 3 {
+		result, err := v.VerifyBot(context.Background(), bot, ip)
+		require.NoError(t, err)
+		assert.True(t, result.Verified)
+	}
+	assert.Equal(t, 2, resolver.lookups)
+	assert.Equal(t, uint64(2), v.CacheStats().Hits)
+
+	// failed lookups are tried again
+	for range 2 {
+		_, err := v.Verify(context.Background(), "Googlebot", netip.MustParseAddr("192.0.2.99"))
+		assert.Error(t, err)
+	}
+	assert.Equal(t, 4, resolver.lookups)
+}
+
+func TestCrawlerVerifier_IPRanges(t *testing.T) {
+	dir := t.TempDir()
+	jsonPath := filepath.Join(dir, "gptbot.json")
+	require.NoError(t, os.WriteFile(jsonPath, []byte(`{
+		"creationTime": "2025-01-01T00:00:00.000000",
+		"prefixes": [{"ipv4Prefix": "192.0.2.0/24"}, {"ipv6Prefix": "2001:db8:1::/48"}]
+	}`), 0o644))
+	jsonRanges, err := LoadIPRangesFile(jsonPath)
+	require.NoError(t, err)
+	assert.Equal(t, []netip.Prefix{netip.MustParsePrefix("192.0.2.0/24"), netip.MustParsePrefix("2001:db8:1::/48")}, jsonRanges)
+
+	textRanges, err := LoadIPRanges(strings.NewReader("# DuckDuckBot\n198.51.100.0/25\n\n198.51.100.200 # single address\n"))
+	require.NoError(t, err)
+	assert.Equal(t, []netip.Prefix{netip.MustParsePrefix("198.51.100.0/25"), netip.MustParsePrefix("198.51.100.200/32")}, textRanges)
+
+	_, err = LoadIPRanges(strings.NewReader("198.51.100.0/25\nnot-an-ip\n"))
+	assert.ErrorContains(t, err, "line 2")
+
+	resolver := &fakeResolver{}
+	v := NewCrawlerVerifier(
+		VerifierResolver(resolver),
+		VerifierIPRanges("GPTBot", jsonRanges...),
+		VerifierIPRanges("DuckDuckBot", textRanges...),
+	)
+	tests := []struct {
+		crawler  string
+		ip       string
+		verified bool
+	}{
+		{"GPTBot", "192.0.2.10", true},
+		{"GPTBot", "2001:db8:1::5", true},
+		{"GPTBot", "198.51.100.1", false},
+		{"DuckDuckBot", "198.51.100.1", true},
+		{"DuckDuckBot", "198.51.100.200", true},
+		{"DuckDuckBot", "198.51.100.201", false},
+	}
+	for _, tc := range tests {
+		result, err := v.Verify(context.Background(), tc.crawler, netip.MustParseAddr(tc.ip))
+		require.NoError(t, err)
+		assert.Equal(t, tc.verified, result.Verified, tc.ip)
+		if tc.verified {
+			assert.Equal(t, VerifiedByIPRange, result.Method)
+		}
+	}
+	// rules without domains never hit the resolver
+	assert.Zero(t, resolver.lookups)
+}